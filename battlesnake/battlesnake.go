@@ -0,0 +1,286 @@
+// Package battlesnake lets bots written for the Battlesnake API
+// (https://docs.battlesnake.com/api) play Nibbles, and serves Nibbles bots
+// over the same API.
+//
+// A round of Nibbles, which lasts until a snake dies or the level is
+// complete, is one Battlesnake game. The playing field is mapped as
+// follows:
+//
+//   - The board is the points of the level, 80x48 for the original levels;
+//     the two rows above them hold the score line and are left out. As in
+//     Battlesnake, (0, 0) is the bottom left corner, so the point in row r
+//     and column c of a playing field of n rows is at x = c-1, y = n-r.
+//   - Walls are hazards, and the ruleset's hazardDamagePerTurn is 100, so
+//     entering a wall is deadly. With WallsAsSnake, walls are instead the
+//     body of an extra snake with ID "walls".
+//   - The number covers two points, both of which are food. Its value is
+//     not part of the schema; the ruleset has minimumFood 1 and
+//     foodSpawnChance 0 because Nibbles places exactly one number at a
+//     time.
+//   - A playing field whose edges are all open (see game.Wrap) has the
+//     ruleset "wrapped"; other open edges cannot be expressed. Neither can
+//     portals, which are walls to Battlesnake bots.
+//   - Snakes have the IDs "sammy" and "jake" and health 100, since snakes
+//     in Nibbles do not starve. Lives are not part of the schema: each life
+//     lost ends a game, and the next life starts a new one.
+package battlesnake
+
+import (
+	"strconv"
+
+	"github.com/gophun/nibbles/game"
+)
+
+// Coord is a point on the board.
+type Coord struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// Snake describes a snake.
+type Snake struct {
+	ID             string         `json:"id"`
+	Name           string         `json:"name"`
+	Health         int            `json:"health"`
+	Body           []Coord        `json:"body"`
+	Head           Coord          `json:"head"`
+	Length         int            `json:"length"`
+	Latency        string         `json:"latency"`
+	Shout          string         `json:"shout"`
+	Squad          string         `json:"squad"`
+	Customizations Customizations `json:"customizations"`
+}
+
+// Customizations describe the looks of a snake.
+type Customizations struct {
+	Color string `json:"color"`
+	Head  string `json:"head"`
+	Tail  string `json:"tail"`
+}
+
+// Board is the playing field.
+type Board struct {
+	Height  int     `json:"height"`
+	Width   int     `json:"width"`
+	Food    []Coord `json:"food"`
+	Hazards []Coord `json:"hazards"`
+	Snakes  []Snake `json:"snakes"`
+}
+
+// Ruleset names the rules of a game.
+type Ruleset struct {
+	Name     string   `json:"name"`
+	Version  string   `json:"version"`
+	Settings Settings `json:"settings"`
+}
+
+// Settings are the parameters of a ruleset.
+type Settings struct {
+	FoodSpawnChance     int `json:"foodSpawnChance"`
+	MinimumFood         int `json:"minimumFood"`
+	HazardDamagePerTurn int `json:"hazardDamagePerTurn"`
+}
+
+// Game describes a game.
+type Game struct {
+	ID      string  `json:"id"`
+	Ruleset Ruleset `json:"ruleset"`
+	Map     string  `json:"map"`
+	Timeout int     `json:"timeout"` // Milliseconds
+	Source  string  `json:"source"`
+}
+
+// GameState is the request body of /start, /move and /end.
+type GameState struct {
+	Game  Game  `json:"game"`
+	Turn  int   `json:"turn"`
+	Board Board `json:"board"`
+	You   Snake `json:"you"`
+}
+
+// MoveResponse is the response body of /move.
+type MoveResponse struct {
+	Move  string `json:"move"`
+	Shout string `json:"shout,omitempty"`
+}
+
+// Info is the response body of the root URL.
+type Info struct {
+	APIVersion string `json:"apiversion"`
+	Author     string `json:"author,omitempty"`
+	Color      string `json:"color,omitempty"`
+	Head       string `json:"head,omitempty"`
+	Tail       string `json:"tail,omitempty"`
+	Version    string `json:"version,omitempty"`
+}
+
+// WallsID is the ID of the snake holding the walls with WallsAsSnake.
+const WallsID = "walls"
+
+var (
+	snakeIDs = []string{"sammy", "jake"}
+	colors   = []string{"#ffff55", "#ff55ff"}
+	moves    = map[game.Direction]string{game.Up: "up", game.Down: "down", game.Left: "left", game.Right: "right"}
+)
+
+// FromState translates the state seen by a Nibbles bot into a Battlesnake
+// game state.
+func FromState(s *game.State, g Game, turn int, wallsAsSnake bool) *GameState {
+	rows := len(s.Board)
+	coord := func(p game.Point) Coord {
+		return Coord{p.Col - 1, rows - p.Row}
+	}
+
+	gs := &GameState{
+		Game: g,
+		Turn: turn,
+		Board: Board{
+			Height:  rows - 2,
+			Width:   len(s.Board[0]),
+			Food:    []Coord{},
+			Hazards: []Coord{},
+		},
+	}
+	gs.Game.Ruleset = Ruleset{
+		Name:    "standard",
+		Version: "nibbles",
+		Settings: Settings{
+			FoodSpawnChance:     0,
+			MinimumFood:         1,
+			HazardDamagePerTurn: 100,
+		},
+	}
+	if s.Wrap == game.WrapBoth {
+		gs.Game.Ruleset.Name = "wrapped"
+	}
+	gs.Game.Map = "nibbles-level-" + strconv.Itoa(s.Level)
+	for _, p := range s.Food {
+		gs.Board.Food = append(gs.Board.Food, coord(p))
+	}
+
+	var walls []Coord
+	for row := 3; row <= rows; row++ {
+		for col := 1; col <= len(s.Board[row-1]); col++ {
+			if c := s.Board[row-1][col-1]; c == game.Wall || c == game.PortalEnd {
+				walls = append(walls, coord(game.Point{Row: row, Col: col}))
+			}
+		}
+	}
+
+	for a, snake := range s.Snakes {
+		if !snake.Alive {
+			continue
+		}
+		bs := Snake{
+			ID:             snakeIDs[a],
+			Name:           snakeIDs[a],
+			Health:         100,
+			Head:           coord(snake.Body[0]),
+			Length:         len(snake.Body),
+			Latency:        "0",
+			Customizations: Customizations{Color: colors[a], Head: "default", Tail: "default"},
+		}
+		for _, p := range snake.Body {
+			bs.Body = append(bs.Body, coord(p))
+		}
+		gs.Board.Snakes = append(gs.Board.Snakes, bs)
+		if a == s.You {
+			gs.You = bs
+		}
+	}
+
+	if wallsAsSnake && len(walls) > 0 {
+		gs.Board.Snakes = append(gs.Board.Snakes, Snake{
+			ID:      WallsID,
+			Name:    WallsID,
+			Health:  100,
+			Body:    walls,
+			Head:    walls[0],
+			Length:  len(walls),
+			Latency: "0",
+		})
+	} else {
+		gs.Board.Hazards = walls
+	}
+	return gs
+}
+
+// ToState translates a Battlesnake game state into the state seen by a
+// Nibbles bot. Hazards and the body of a snake with ID WallsID become
+// walls, and everything outside the board is a wall.
+func ToState(gs *GameState) *game.State {
+	rows, cols := gs.Board.Height+2, gs.Board.Width
+	board := make([][]byte, rows)
+	for row := range board {
+		board[row] = make([]byte, cols)
+		for col := range board[row] {
+			board[row][col] = game.Free
+			if row < 2 {
+				board[row][col] = game.Wall
+			}
+		}
+	}
+	point := func(c Coord) game.Point {
+		return game.Point{Row: rows - c.Y, Col: c.X + 1}
+	}
+	onBoard := func(c Coord) bool {
+		return c.X >= 0 && c.X < gs.Board.Width && c.Y >= 0 && c.Y < gs.Board.Height
+	}
+
+	s := &game.State{Number: 1}
+	if gs.Game.Ruleset.Name == "wrapped" {
+		s.Wrap = game.WrapBoth
+	}
+	for _, c := range gs.Board.Hazards {
+		if onBoard(c) {
+			p := point(c)
+			board[p.Row-1][p.Col-1] = game.Wall
+		}
+	}
+	for _, c := range gs.Board.Food {
+		s.Food = append(s.Food, point(c))
+	}
+	for _, snake := range gs.Board.Snakes {
+		mark := byte(game.Wall)
+		if snake.ID != WallsID {
+			if snake.ID == gs.You.ID {
+				s.You = len(s.Snakes)
+			}
+			mark = byte('1' + len(s.Snakes)%9)
+			ss := game.SnakeState{Direction: game.Up, Lives: 1, Alive: true}
+			for _, c := range snake.Body {
+				ss.Body = append(ss.Body, point(c))
+			}
+			if len(ss.Body) == 0 {
+				ss.Body = []game.Point{point(snake.Head)}
+			}
+			s.Snakes = append(s.Snakes, ss)
+		}
+		for _, c := range snake.Body {
+			if onBoard(c) {
+				p := point(c)
+				board[p.Row-1][p.Col-1] = mark
+			}
+		}
+	}
+	for _, row := range board {
+		s.Board = append(s.Board, string(row))
+	}
+
+	// Find out where the snakes are heading from their necks, once the
+	// board is there to move on
+	for i := range s.Snakes {
+		ss := &s.Snakes[i]
+		for _, p := range ss.Body[1:] {
+			if p != ss.Body[0] {
+				for _, dir := range []game.Direction{game.Up, game.Down, game.Left, game.Right} {
+					if s.Move(p, dir) == ss.Body[0] {
+						ss.Direction = dir
+					}
+				}
+				break
+			}
+		}
+	}
+	return s
+}
@@ -0,0 +1,55 @@
+package battlesnake
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gophun/nibbles/game"
+)
+
+// playedState returns the state seen by snake 0 after a few moves on the
+// first level, with the snakes heading in different directions.
+func playedState(t *testing.T) *game.State {
+	t.Helper()
+	g := game.New(2, []int{14, 13, 12, 1, 15, 4, 3, 2}, 1)
+	g.Level(game.StartOver)
+	g.PlaceNumber()
+	for i := 0; i < 5; i++ {
+		if i == 3 {
+			g.Turn(0, game.Up)
+		}
+		if ev := g.Step(); ev.Died || ev.LevelComplete {
+			t.Fatalf("move %d ended the round", i+1)
+		}
+	}
+	return g.State(0)
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	for _, wallsAsSnake := range []bool{false, true} {
+		s := playedState(t)
+		gs := FromState(s, Game{ID: "test"}, 5, wallsAsSnake)
+		got := ToState(gs)
+
+		if !reflect.DeepEqual(got.Board[2:], s.Board[2:]) {
+			t.Errorf("wallsAsSnake %v: board\n%v\nwant\n%v", wallsAsSnake, got.Board[2:], s.Board[2:])
+		}
+		if !reflect.DeepEqual(got.Food, s.Food) {
+			t.Errorf("wallsAsSnake %v: food %v, want %v", wallsAsSnake, got.Food, s.Food)
+		}
+		if got.You != s.You {
+			t.Errorf("wallsAsSnake %v: you %d, want %d", wallsAsSnake, got.You, s.You)
+		}
+		if len(got.Snakes) != len(s.Snakes) {
+			t.Fatalf("wallsAsSnake %v: %d snakes, want %d", wallsAsSnake, len(got.Snakes), len(s.Snakes))
+		}
+		for a, want := range s.Snakes {
+			if !reflect.DeepEqual(got.Snakes[a].Body, want.Body) {
+				t.Errorf("wallsAsSnake %v: snake %d body %v, want %v", wallsAsSnake, a, got.Snakes[a].Body, want.Body)
+			}
+			if got.Snakes[a].Direction != want.Direction {
+				t.Errorf("wallsAsSnake %v: snake %d heading %v, want %v", wallsAsSnake, a, got.Snakes[a].Direction, want.Direction)
+			}
+		}
+	}
+}
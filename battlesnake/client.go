@@ -0,0 +1,149 @@
+package battlesnake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gophun/nibbles/game"
+)
+
+var gameCount int64
+
+// Client is a Nibbles bot played by a Battlesnake server. If the server
+// does not answer a move in time or answers with an error, the snake keeps
+// its direction.
+type Client struct {
+	URL          string        // Base URL of the server
+	Timeout      time.Duration // Time allowed per move
+	WallsAsSnake bool          // Send walls as a snake instead of hazards
+	Log          io.Writer     // Receives errors; may be nil
+
+	http  *http.Client
+	id    string
+	round int
+	turn  int
+	last  *GameState // Last state sent in the current game, nil if there is none
+	key   [3]int     // Level and lives of the snakes when the round started
+}
+
+// NewClient returns a bot played by the Battlesnake server at the given
+// base URL. Errors are written to log, which may be nil.
+func NewClient(url string, log io.Writer) *Client {
+	return &Client{
+		URL:     strings.TrimSuffix(url, "/"),
+		Timeout: 500 * time.Millisecond,
+		Log:     log,
+		http:    &http.Client{},
+		id:      fmt.Sprintf("nibbles-%d-%d", time.Now().Unix(), atomic.AddInt64(&gameCount, 1)),
+	}
+}
+
+// Decide implements game.Bot.
+func (c *Client) Decide(s *game.State) game.Direction {
+	fallback := s.Snakes[s.You].Direction
+
+	// A new round starts a new Battlesnake game
+	key := [3]int{s.Level}
+	for a, snake := range s.Snakes {
+		key[a+1] = snake.Lives
+	}
+	if c.last == nil || key != c.key {
+		c.end()
+		c.round++
+		c.turn = 0
+		c.key = key
+	}
+	gs := FromState(s, c.game(), c.turn, c.WallsAsSnake)
+	if c.turn == 0 {
+		c.post("/start", gs, nil)
+	}
+	c.last = gs
+	c.turn++
+
+	var move MoveResponse
+	if err := c.post("/move", gs, &move); err != nil {
+		return fallback
+	}
+	for dir, name := range moves {
+		if name == move.Move {
+			return dir
+		}
+	}
+	c.logf("invalid move %q", move.Move)
+	return fallback
+}
+
+// End implements game.Ender. It ends the current game on the server with
+// the board after the last move.
+func (c *Client) End(s *game.State) {
+	if c.last != nil {
+		c.last = FromState(s, c.game(), c.turn, c.WallsAsSnake)
+	}
+	c.end()
+}
+
+// Close ends the current game on the server.
+func (c *Client) Close() error {
+	c.end()
+	return nil
+}
+
+// game returns the description of the current game.
+func (c *Client) game() Game {
+	return Game{
+		ID:      fmt.Sprintf("%s-%d", c.id, c.round),
+		Timeout: int(c.Timeout / time.Millisecond),
+		Source:  "custom",
+	}
+}
+
+// end ends the current game on the server with the last state sent, or
+// the final one if the game is over.
+func (c *Client) end() {
+	if c.last != nil {
+		c.post("/end", c.last, nil)
+		c.last = nil
+	}
+}
+
+// post sends the game state to the given endpoint and decodes the
+// response into v, unless v is nil.
+func (c *Client) post(path string, gs *GameState, v interface{}) error {
+	body, err := json.Marshal(gs)
+	if err != nil {
+		panic(err)
+	}
+	c.http.Timeout = c.Timeout
+	resp, err := c.http.Post(c.URL+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		c.logf("%v", err)
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("%s: %s", path, resp.Status)
+		c.logf("%v", err)
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		err = fmt.Errorf("%s: %v", path, err)
+		c.logf("%v", err)
+		return err
+	}
+	return nil
+}
+
+func (c *Client) logf(format string, a ...interface{}) {
+	if c.Log != nil {
+		fmt.Fprintf(c.Log, "%s: "+format+"\n", append([]interface{}{c.URL}, a...)...)
+	}
+}
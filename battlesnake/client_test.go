@@ -0,0 +1,91 @@
+package battlesnake
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gophun/nibbles/game"
+)
+
+// recorder is a bot that always heads up and records what it is shown.
+type recorder struct {
+	moves  int
+	ends   []*game.State
+	closed int
+}
+
+func (r *recorder) Decide(s *game.State) game.Direction {
+	r.moves++
+	return game.Up
+}
+
+func (r *recorder) End(s *game.State) {
+	r.ends = append(r.ends, s)
+}
+
+func (r *recorder) Close() error {
+	r.closed++
+	return nil
+}
+
+func TestClientServer(t *testing.T) {
+	var mu sync.Mutex
+	var bots []*recorder
+	srv := httptest.NewServer(NewServer(func() game.Bot {
+		mu.Lock()
+		defer mu.Unlock()
+		r := &recorder{}
+		bots = append(bots, r)
+		return r
+	}))
+	defer srv.Close()
+	games := func() []*recorder {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*recorder(nil), bots...)
+	}
+
+	c := NewClient(srv.URL, nil)
+	c.Timeout = 5 * time.Second
+	g := game.New(2, game.DefaultColors, 1)
+	g.Level(game.StartOver)
+	g.PlaceNumber()
+	for i := 0; i < 3; i++ {
+		if dir := c.Decide(g.State(0)); dir != game.Up {
+			t.Errorf("move %d: Decide = %v, want up", i+1, dir)
+		}
+		g.Step()
+	}
+	final := g.State(0)
+	c.End(final)
+
+	played := games()
+	if len(played) != 1 {
+		t.Fatalf("server played %d games, want 1", len(played))
+	}
+	r := played[0]
+	if r.moves != 3 {
+		t.Errorf("server bot made %d moves, want 3", r.moves)
+	}
+	if r.closed != 1 {
+		t.Errorf("server bot closed %d times, want 1", r.closed)
+	}
+	if len(r.ends) != 1 {
+		t.Fatalf("server bot shown %d ends, want 1", len(r.ends))
+	}
+	for a, want := range final.Snakes {
+		if got := r.ends[0].Snakes[a].Body; !reflect.DeepEqual(got, want.Body) {
+			t.Errorf("snake %d at the end: %v, want the final board's %v", a, got, want.Body)
+		}
+	}
+
+	// The next move starts a new game, which Close ends
+	c.Decide(g.State(0))
+	c.Close()
+	if played = games(); len(played) != 2 || len(played[1].ends) != 1 {
+		t.Errorf("after a second game: %d games, want 2, each ended", len(played))
+	}
+}
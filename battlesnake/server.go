@@ -0,0 +1,83 @@
+package battlesnake
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/gophun/nibbles/game"
+)
+
+// Server answers Battlesnake API requests with the moves of Nibbles bots,
+// one bot per game.
+type Server struct {
+	NewBot func() game.Bot
+	Info   Info
+
+	mu    sync.Mutex
+	games map[string]game.Bot
+}
+
+// NewServer returns a server playing the bots created by newBot.
+func NewServer(newBot func() game.Bot) *Server {
+	return &Server{
+		NewBot: newBot,
+		Info:   Info{APIVersion: "1", Author: "gophun", Color: colors[0], Head: "default", Tail: "default"},
+		games:  make(map[string]game.Bot),
+	}
+}
+
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/" {
+		writeJSON(w, srv.Info)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var gs GameState
+	if err := json.NewDecoder(r.Body).Decode(&gs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/start":
+		srv.bot(gs.Game.ID)
+	case "/move":
+		s := ToState(&gs)
+		writeJSON(w, MoveResponse{Move: moves[srv.bot(gs.Game.ID).Decide(s)]})
+	case "/end":
+		srv.mu.Lock()
+		bot := srv.games[gs.Game.ID]
+		delete(srv.games, gs.Game.ID)
+		srv.mu.Unlock()
+		if e, ok := bot.(game.Ender); ok {
+			e.End(ToState(&gs))
+		}
+		if c, ok := bot.(io.Closer); ok {
+			c.Close()
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// bot returns the bot playing the given game.
+func (srv *Server) bot(id string) game.Bot {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	bot, ok := srv.games[id]
+	if !ok {
+		bot = srv.NewBot()
+		srv.games[id] = bot
+	}
+	return bot
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
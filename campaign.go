@@ -0,0 +1,237 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gophun/nibbles/game"
+	. "github.com/gophun/nibbles/internal/basic"
+)
+
+// OpenPack loads the level pack in file, a directory or a zip archive.
+func OpenPack(file string) (*game.Pack, error) {
+	pack, err := openPack(file)
+	if err != nil {
+		var perr *game.ParseError
+		if errors.As(err, &perr) {
+			perr.File = filepath.Join(file, perr.File)
+		} else {
+			err = fmt.Errorf("%s: %v", file, err)
+		}
+		return nil, err
+	}
+	return pack, nil
+}
+
+func openPack(file string) (*game.Pack, error) {
+	name := strings.TrimSuffix(filepath.Base(file), ".zip")
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return game.LoadPack(os.DirFS(file), ".", name)
+	}
+	if filepath.Ext(file) != ".zip" {
+		return nil, errors.New("not a directory or zip archive")
+	}
+	r, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	// Archives often hold the directory of the pack instead of its files.
+	dir := "."
+	if entries, err := fs.ReadDir(r, "."); err == nil && len(entries) == 1 && entries[0].IsDir() {
+		dir = entries[0].Name()
+	}
+	return game.LoadPack(r, dir, name)
+}
+
+// FindPacks returns the original levels and the level packs in dir,
+// directories and zip archives, in the order of their names. Packs that
+// cannot be loaded are left out and reported in errs.
+func FindPacks(dir string) (packs []*game.Pack, errs []error) {
+	packs = append(packs, game.DefaultPack())
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return packs, nil
+		}
+		return packs, []error{err}
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) != ".zip" {
+			continue
+		}
+		pack, err := OpenPack(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		packs = append(packs, pack)
+	}
+	return packs, errs
+}
+
+// Progress holds, for each player profile, the highest level reached in
+// each pack, by pack name.
+type Progress map[string]map[string]int
+
+// configDir returns the directory of the level packs and saved progress,
+// or "." if there is no configuration directory.
+func configDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "."
+	}
+	return filepath.Join(dir, "nibbles")
+}
+
+func progressFile() string {
+	return filepath.Join(configDir(), "progress.json")
+}
+
+// LoadProgress reads the saved progress. It is empty if nothing was saved.
+func LoadProgress() Progress {
+	progress := Progress{}
+	if data, err := os.ReadFile(progressFile()); err == nil {
+		json.Unmarshal(data, &progress)
+	}
+	return progress
+}
+
+// Save saves the progress.
+func (p Progress) Save() error {
+	file := progressFile()
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0o644)
+}
+
+// Highest returns the highest level profile reached in pack, at least 1.
+func (p Progress) Highest(profile string, pack *game.Pack) int {
+	if level := p[profile][pack.Name]; level > 1 {
+		return level
+	}
+	return 1
+}
+
+// A Campaign is a game played through a level pack that saves the
+// highest level reached.
+type Campaign struct {
+	Progress Progress
+	Profile  string
+	Pack     *game.Pack
+}
+
+// Reached records that the players reached level. Reaching the level
+// after the last one of the pack means the pack is completed.
+func (c *Campaign) Reached(level int) {
+	if level > len(c.Pack.Levels)+1 {
+		level = len(c.Pack.Levels) + 1
+	}
+	if level <= c.Progress.Highest(c.Profile, c.Pack) {
+		return
+	}
+	if c.Progress[c.Profile] == nil {
+		c.Progress[c.Profile] = make(map[string]int)
+	}
+	c.Progress[c.Profile][c.Pack.Name] = level
+	c.Progress.Save()
+}
+
+// ChooseCampaign lets the players choose their profile, a level pack and
+// the level to start at. Starting below the highest level reached can be
+// practice, which plays only that level.
+func ChooseCampaign(packs []*game.Pack) {
+	Color(7, 0)
+	Cls()
+
+	profile = ""
+	for profile == "" {
+		Locate(3, 4)
+		Print(Space(70))
+		Locate(3, 20)
+		profile = strings.TrimSpace(Input("Your name"))
+	}
+	progress := LoadProgress()
+
+	if len(packs) > 14 {
+		packs = packs[:14]
+	}
+	Locate(5, 20)
+	Print("Level packs")
+	for i, pack := range packs {
+		status := fmt.Sprintf("level %d of %d", progress.Highest(profile, pack), len(pack.Levels))
+		if progress.Highest(profile, pack) > len(pack.Levels) {
+			status = "completed"
+		}
+		Locate(6+i, 20)
+		PrintUsing("%2d  %-24s %s", i+1, Left(pack.Name, 24), status)
+	}
+
+	n := 1
+	if len(packs) > 1 {
+		n = 0
+		for n < 1 || n > len(packs) {
+			Locate(21, 4)
+			Print(Space(70))
+			Locate(21, 20)
+			n = Val(Input("Level pack (1 to " + Str(len(packs)) + ")"))
+		}
+	}
+	pack := packs[n-1]
+
+	highest := progress.Highest(profile, pack)
+	unlocked := highest
+	if unlocked > len(pack.Levels) {
+		unlocked = len(pack.Levels)
+	}
+	start := 1
+	if unlocked > 1 {
+		start = 0
+		for start < 1 || start > unlocked {
+			Locate(22, 4)
+			Print(Space(70))
+			Locate(22, 20)
+			text := Input("Start at level (1 to " + Str(unlocked) + ", Enter for " + Str(unlocked) + ")")
+			start = Val(text)
+			if text == "" {
+				start = unlocked
+			}
+		}
+	}
+
+	levels = pack.Levels
+	packName = pack.Name
+	repeatLast = false
+	startLevel = start
+	campaign = &Campaign{Progress: progress, Profile: profile, Pack: pack}
+	if start < highest {
+		practice := ""
+		for practice != "Y" && practice != "N" {
+			Locate(23, 4)
+			Print(Space(70))
+			Locate(23, 20)
+			practice = UCase(Input("Practice only this level (Y or N)"))
+		}
+		if practice == "Y" {
+			levels = []*game.Level{pack.Levels[start-1]}
+			repeatLast = true
+			startLevel = 1
+			campaign = nil
+		}
+	}
+}
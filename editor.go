@@ -0,0 +1,390 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/gophun/nibbles/game"
+	. "github.com/gophun/nibbles/internal/basic"
+)
+
+// Delay between moves when test-playing a level, as for skill level 70.
+const testSpeed = 61
+
+// Edit runs the edit command, a level editor for the terminal. It returns
+// the exit code.
+func Edit(args []string) int {
+	flags := flag.NewFlagSet("edit", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: nibbles edit [flags] [file]")
+		flags.PrintDefaults()
+	}
+	size := flags.String("size", "80x50", "`size` of a new level, columns x rows")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+	file := flags.Arg(0)
+	rows, cols, err := parseSize(*size)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nibbles:", err)
+		return 2
+	}
+	if rows-game.FirstMapRow+1 < game.MinMapRows || rows%2 != 0 || cols < game.MinMapCols {
+		fmt.Fprintf(os.Stderr, "nibbles: size %s too small or with an odd number of rows\n", *size)
+		return 2
+	}
+
+	lvl := NewLevel(rows, cols)
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err == nil {
+			lvl, err = game.ParseLevel(file, bytes.NewReader(data))
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintln(os.Stderr, "nibbles:", err)
+			return 1
+		}
+	}
+
+	Screen(0)
+	Width(80, 25)
+	defer Reset()
+	SetColors("C")
+	DrawScreen()
+	EditLevel(lvl, file)
+	Color(7, 0)
+	Cls()
+	return 0
+}
+
+// NewLevel returns an empty level of rows by cols points surrounded by a
+// wall.
+func NewLevel(rows, cols int) *game.Level {
+	mid := rows / 2
+	lvl := &game.Level{
+		Name:    "New Level",
+		Numbers: 9,
+		Rows:    rows,
+		Cols:    cols,
+		Start: [2]game.Start{
+			{Point: game.Point{Row: mid, Col: cols * 5 / 8}, Direction: game.Right},
+			{Point: game.Point{Row: mid, Col: cols * 3 / 8}, Direction: game.Left},
+		},
+	}
+	for col := 1; col <= cols; col++ {
+		lvl.Walls = append(lvl.Walls, game.Point{Row: game.FirstMapRow, Col: col}, game.Point{Row: rows, Col: col})
+	}
+	for row := game.FirstMapRow + 1; row < rows; row++ {
+		lvl.Walls = append(lvl.Walls, game.Point{Row: row, Col: 1}, game.Point{Row: row, Col: cols})
+	}
+	return lvl
+}
+
+// EditLevel lets the user edit the level and save it to file. If file is
+// empty, the user is asked for a file name when saving.
+func EditLevel(lvl *game.Level, file string) {
+	rows, cols := lvl.Size()
+	ResizeArena(rows, cols)
+	walls := make([][]bool, rows+1)
+	for row := range walls {
+		walls[row] = make([]bool, cols+1)
+	}
+	for _, p := range lvl.Walls {
+		walls[p.Row][p.Col] = true
+	}
+
+	// startAt returns the player starting at a point, or -1
+	startAt := func(p game.Point) int {
+		for a, start := range lvl.Start {
+			if start.Point == p {
+				return a
+			}
+		}
+		return -1
+	}
+	// portalAt returns the portal with an end at a point, or -1
+	portalAt := func(p game.Point) int {
+		for i, portal := range lvl.Portals {
+			if portal[0] == p || portal[1] == p {
+				return i
+			}
+		}
+		return -1
+	}
+	var pending *game.Point // First end of a portal being placed
+	// Obstacles are shown where they start, in dark gray, pressure plates
+	// in their color, and walls changed by triggers in dark red if they are
+	// up at the start, or else in brown.
+	grid := func() [][]bool {
+		g := make([][]bool, rows+1)
+		for row := range g {
+			g[row] = make([]bool, cols+1)
+		}
+		return g
+	}
+	obstacles, plates, triggered := grid(), grid(), grid()
+	for i := range lvl.Obstacles {
+		for _, p := range lvl.Obstacles[i].Frames()[0].Points {
+			if lvl.Contains(p) {
+				obstacles[p.Row][p.Col] = true
+			}
+		}
+	}
+	for i := range lvl.Triggers {
+		t := &lvl.Triggers[i]
+		if t.Cause == game.OnPlate && lvl.Contains(t.Plate) {
+			plates[t.Plate.Row][t.Plate.Col] = true
+		}
+		for _, p := range t.Points() {
+			if lvl.Contains(p) {
+				triggered[p.Row][p.Col] = true
+			}
+		}
+	}
+	// triggerAt returns the first trigger with its plate or a wall at a
+	// point, or nil
+	triggerAt := func(p game.Point) *game.Trigger {
+		for i := range lvl.Triggers {
+			t := &lvl.Triggers[i]
+			if t.Cause == game.OnPlate && t.Plate == p {
+				return t
+			}
+			for _, q := range t.Points() {
+				if q == p {
+					return t
+				}
+			}
+		}
+		return nil
+	}
+	rings := func() {
+		var extra []game.Point
+		if pending != nil {
+			extra = append(extra, *pending)
+		}
+		SetRings(lvl.Portals, extra)
+	}
+	// colorAt returns the color of a point of the level
+	colorAt := func(p game.Point) int {
+		if a := startAt(p); a >= 0 {
+			return colorTable[a]
+		}
+		if portalAt(p) >= 0 || pending != nil && *pending == p {
+			return colorTable[6]
+		}
+		switch {
+		case triggered[p.Row][p.Col] && walls[p.Row][p.Col]:
+			return 4
+		case triggered[p.Row][p.Col]:
+			return 6
+		case plates[p.Row][p.Col]:
+			return colorTable[7]
+		}
+		if walls[p.Row][p.Col] {
+			return colorTable[2]
+		}
+		if obstacles[p.Row][p.Col] {
+			return 8
+		}
+		return colorTable[3]
+	}
+	cur := game.Point{Row: rows / 2, Col: cols / 2}
+	draw := func() {
+		ResizeArena(rows, cols)
+		views = nil
+		rings()
+		for row := game.FirstMapRow; row <= rows; row++ {
+			for col := 1; col <= cols; col++ {
+				p := game.Point{Row: row, Col: col}
+				if c := colorAt(p); c != colorTable[3] {
+					Set(row, col, c)
+				}
+			}
+		}
+		SetViews([]game.Point{cur})
+	}
+	// update copies the walls into the level
+	update := func() {
+		lvl.Walls = nil
+		for row := game.FirstMapRow; row <= rows; row++ {
+			for col := 1; col <= cols; col++ {
+				if walls[row][col] {
+					lvl.Walls = append(lvl.Walls, game.Point{Row: row, Col: col})
+				}
+			}
+		}
+	}
+	status := func(text string) {
+		Color(15, colorTable[3])
+		Locate(1, 1)
+		Print(Left(text+Space(cols), cols))
+	}
+
+	pen := "up" // up, draw or erase
+	changed := false
+	message := ""
+	draw()
+	for {
+		status(fmt.Sprintf(" %-26s %2d,%-2d  Pen %-5s  Sammy %-5s  Jake %-5s %s",
+			Left(lvl.Name+Space(26), 26), cur.Row, cur.Col, pen, lvl.Start[0].Direction, lvl.Start[1].Direction, message))
+		Locate(2, 1)
+		Print(" Space:Wall D:Draw E:Erase 1/2:Start P:Portal W:Wrap N:Name T:Test S:Save Q:Quit")
+		message = ""
+
+		// Show the cursor
+		Set(cur.Row, cur.Col, 15)
+		kbd := ""
+		for kbd == "" {
+			kbd = InKey()
+			SleepMillis(10)
+		}
+		Set(cur.Row, cur.Col, colorAt(cur))
+
+		dir := game.Direction(0)
+		switch kbd {
+		case "\x00H":
+			dir = game.Up
+		case "\x00P":
+			dir = game.Down
+		case "\x00K":
+			dir = game.Left
+		case "\x00M":
+			dir = game.Right
+		case " ":
+			if startAt(cur) < 0 && colorAt(cur) != colorTable[6] {
+				walls[cur.Row][cur.Col] = !walls[cur.Row][cur.Col]
+				changed = true
+			}
+		case "d", "D":
+			if pen == "draw" {
+				pen = "up"
+			} else {
+				pen = "draw"
+			}
+		case "e", "E":
+			if pen == "erase" {
+				pen = "up"
+			} else {
+				pen = "erase"
+			}
+		case "1", "2":
+			a := int(kbd[0] - '1')
+			start := &lvl.Start[a]
+			if start.Point == cur {
+				// Turn clockwise
+				start.Direction = map[game.Direction]game.Direction{
+					game.Up: game.Right, game.Right: game.Down, game.Down: game.Left, game.Left: game.Up,
+				}[start.Direction]
+			} else if lvl.Start[1-a].Point != cur && colorAt(cur) != colorTable[6] {
+				old := start.Point
+				start.Point = cur
+				walls[cur.Row][cur.Col] = false
+				Set(old.Row, old.Col, colorAt(old))
+			}
+			changed = true
+		case "n", "N":
+			status("")
+			Locate(1, 2)
+			if name := Input("Name"); name != "" {
+				lvl.Name = name
+				changed = true
+			}
+		case "p", "P":
+			switch i := portalAt(cur); {
+			case i >= 0:
+				// Remove both ends
+				portal := lvl.Portals[i]
+				lvl.Portals = append(lvl.Portals[:i:i], lvl.Portals[i+1:]...)
+				for _, p := range portal {
+					Set(p.Row, p.Col, colorAt(p))
+				}
+				changed = true
+			case startAt(cur) >= 0:
+			case pending == nil:
+				p := cur
+				pending = &p
+				walls[cur.Row][cur.Col] = false
+				message = "Put the other end"
+			case *pending == cur:
+				pending = nil
+			case len(lvl.Portals) == game.MaxPortals:
+				message = "Too many portals"
+			default:
+				lvl.Portals = append(lvl.Portals, game.Portal{*pending, cur})
+				pending = nil
+				walls[cur.Row][cur.Col] = false
+				changed = true
+			}
+			rings()
+		case "w", "W":
+			lvl.Wrap = (lvl.Wrap + 1) % (game.WrapBoth + 1)
+			message = "Wrap " + lvl.Wrap.String()
+			changed = true
+		case "t", "T":
+			update()
+			saved := levels
+			levels, repeatLast = []*game.Level{lvl}, true
+			testPlay = true
+			PlayNibbles(2, testSpeed, "N", [2]int{Human, game.Average})
+			testPlay = false
+			levels, repeatLast = saved, false
+			draw()
+		case "s", "S":
+			update()
+			if file == "" {
+				status("")
+				Locate(1, 2)
+				file = Input("Save as")
+			}
+			if file != "" {
+				if err := SaveLevel(lvl, file); err != nil {
+					message = err.Error()
+				} else {
+					message = "Saved"
+					changed = false
+				}
+			}
+		case "q", "Q", "Esc":
+			if !changed {
+				return
+			}
+			status("")
+			Locate(1, 2)
+			if UCase(Input("Quit without saving (Y or N)")) == "Y" {
+				return
+			}
+		}
+
+		if dir != 0 {
+			next := dir.Step(cur)
+			if lvl.Contains(next) {
+				cur = next
+				FollowViews([]game.Point{cur})
+				if t := triggerAt(cur); t != nil {
+					message = "Trigger " + t.String()
+				}
+			}
+			if pen != "up" && startAt(cur) < 0 && colorAt(cur) != colorTable[6] {
+				walls[cur.Row][cur.Col] = pen == "draw"
+				Set(cur.Row, cur.Col, colorAt(cur))
+				changed = true
+			}
+		}
+		Set(cur.Row, cur.Col, colorAt(cur))
+	}
+}
+
+// SaveLevel writes the level to the given file.
+func SaveLevel(lvl *game.Level, file string) error {
+	var b bytes.Buffer
+	lvl.WriteTo(&b)
+	return os.WriteFile(file, b.Bytes(), 0666)
+}
@@ -0,0 +1,235 @@
+// Package env is a reinforcement learning environment for Nibbles in the
+// style of OpenAI Gym. It plays the levels of package game without a
+// screen.
+package env
+
+import (
+	"github.com/gophun/nibbles/game"
+)
+
+// Channels of an Observation. Every snake has two channels, starting with
+// snake 0 at SnakeBody and SnakeHead.
+const (
+	Walls = iota
+	Food
+	SnakeBody
+	SnakeHead
+)
+
+// Channel returns the channel of snake a's body (or, with head, its head).
+func Channel(a int, head bool) int {
+	if head {
+		return SnakeHead + 2*a
+	}
+	return SnakeBody + 2*a
+}
+
+// Observation is the playing field as a tensor of channels by rows by
+// columns. An element is 1 if the channel's feature is present at that
+// point, otherwise 0.
+type Observation struct {
+	Shape [3]int  `json:"shape"`
+	Data  []uint8 `json:"data"`
+}
+
+// At returns the element for the given channel and point.
+func (o Observation) At(channel int, p game.Point) uint8 {
+	return o.Data[(channel*o.Shape[1]+p.Row-1)*o.Shape[2]+p.Col-1]
+}
+
+// Reward holds the reward of every snake for a step.
+type Reward []float64
+
+// Info describes the state of the game after a step.
+type Info struct {
+	Level  int         `json:"level"`
+	Steps  int         `json:"steps"`
+	Scores []int       `json:"scores"`
+	Lives  []int       `json:"lives"`
+	Events game.Events `json:"events"`
+}
+
+// Rewards configures the reward shaping. Every value is added to the
+// reward of a snake whenever the described thing happens to it.
+type Rewards struct {
+	Point    float64 `json:"point"`    // Per point scored by eating a number
+	Death    float64 `json:"death"`    // Losing a life
+	Step     float64 `json:"step"`     // Every step the snake survives
+	Level    float64 `json:"level"`    // Completing a level, for all snakes alive
+	Approach float64 `json:"approach"` // Per point the head got closer to the number
+}
+
+// Config configures an environment.
+type Config struct {
+	Players    int           `json:"players"`    // Number of snakes, 1 or 2
+	Levels     []*game.Level `json:"-"`          // Levels to play; nil for the original levels
+	Level      int           `json:"level"`      // Level to start on
+	Advance    bool          `json:"advance"`    // Move on to the next level once a level is complete, instead of repeating it
+	OneLife    bool          `json:"one_life"`   // End the episode when a snake dies
+	Borderless bool          `json:"borderless"` // Play without borders, see game.Game.Borderless
+	Rules      *game.Rules   `json:"rules"`      // Rules to play by; nil for game.Classic
+	MaxSteps   int           `json:"max_steps"`  // End the episode after this many steps; 0 means no limit
+	Rewards    Rewards       `json:"rewards"`
+}
+
+// DefaultConfig is a single player game on level 1 that ends with the
+// first death.
+var DefaultConfig = Config{
+	Players:  1,
+	Level:    1,
+	OneLife:  true,
+	MaxSteps: 10000,
+	Rewards: Rewards{
+		Point: 1,
+		Death: -10,
+		Level: 10,
+	},
+}
+
+// Env is an environment playing a game of Nibbles.
+type Env struct {
+	Config Config
+
+	g     *game.Game
+	steps int
+}
+
+// New returns an environment with the given configuration.
+func New(cfg Config) *Env {
+	return &Env{Config: cfg}
+}
+
+// Reset starts a new episode and returns the first observation.
+func (e *Env) Reset(seed int64) Observation {
+	e.g = game.New(e.Config.Players, game.DefaultColors, seed)
+	if e.Config.Levels != nil {
+		e.g.Levels = e.Config.Levels
+	}
+	e.g.Borderless = e.Config.Borderless
+	if e.Config.Rules != nil {
+		e.g.SetRules(*e.Config.Rules)
+	}
+	e.g.CurLevel = e.Config.Level
+	if e.g.CurLevel < 1 {
+		e.g.CurLevel = 1
+	}
+	e.g.Level(game.SameLevel)
+	e.g.PlaceNumber()
+	e.steps = 0
+	return e.observe()
+}
+
+// Step turns every snake into the direction given in actions, where 0
+// (or any other value that is not a direction) means to keep going, and
+// moves the snakes on. It returns the new
+// observation, the rewards, whether the episode is over and information
+// about the game.
+func (e *Env) Step(actions []game.Direction) (Observation, Reward, bool, Info) {
+	g := e.g
+	players := g.Players
+	rewards := &e.Config.Rewards
+
+	var before [2]game.Snake
+	var dist [2]int
+	for a := 0; a < players; a++ {
+		if a < len(actions) && actions[a] >= game.Up && actions[a] <= game.Right {
+			g.Turn(a, actions[a])
+		}
+		before[a] = g.Snakes[a]
+		dist[a] = e.distance(a)
+	}
+
+	ev := g.Step()
+	e.steps++
+
+	reward := make(Reward, players)
+	for a := 0; a < players; a++ {
+		s := &g.Snakes[a]
+		points := s.Eaten - before[a].Eaten
+		if !s.Alive {
+			reward[a] += rewards.Death
+		} else {
+			reward[a] += rewards.Step
+			if ev.LevelComplete {
+				reward[a] += rewards.Level
+			}
+		}
+		reward[a] += rewards.Point * float64(points)
+		if points == 0 && s.Alive && dist[a] >= 0 {
+			reward[a] += rewards.Approach * float64(dist[a]-e.distance(a))
+		}
+	}
+
+	done := g.Over() || (e.Config.MaxSteps > 0 && e.steps >= e.Config.MaxSteps)
+	if ev.Died {
+		done = done || e.Config.OneLife
+	}
+	switch {
+	case ev.LevelComplete && e.Config.Advance:
+		g.Level(game.NextLevel)
+	case ev.LevelComplete, ev.Died:
+		g.Level(game.SameLevel)
+	}
+	g.PlaceNumber()
+
+	info := Info{Level: g.CurLevel, Steps: e.steps, Events: ev}
+	for a := 0; a < players; a++ {
+		info.Scores = append(info.Scores, g.Snakes[a].Score)
+		info.Lives = append(info.Lives, g.Snakes[a].Lives)
+	}
+	return e.observe(), reward, done, info
+}
+
+// distance returns the distance between the head of snake a and the
+// number, or -1 if there is no number.
+func (e *Env) distance(a int) int {
+	g := e.g
+	if g.NumberRow == 0 {
+		return -1
+	}
+	s := g.Snakes[a]
+	d := abs(s.Col - g.NumberCol)
+	if row := s.Row - g.NumberRow*2; row < 0 {
+		d -= row + 1 // The upper point of the number is one row above
+	} else {
+		d += row
+	}
+	return d
+}
+
+// observe builds the observation of the current state.
+func (e *Env) observe() Observation {
+	g := e.g
+	rows, cols := g.Arena.Size()
+	o := Observation{Shape: [3]int{2 + 2*g.Players, rows, cols}}
+	o.Data = make([]uint8, o.Shape[0]*rows*cols)
+	set := func(channel int, p game.Point) {
+		o.Data[(channel*rows+p.Row-1)*cols+p.Col-1] = 1
+	}
+	for row := 1; row <= rows; row++ {
+		for col := 1; col <= cols; col++ {
+			if g.Arena.PointIsThere(row, col) {
+				set(Walls, game.Point{Row: row, Col: col})
+			}
+		}
+	}
+	if g.NumberRow != 0 {
+		set(Food, game.Point{Row: g.NumberRow*2 - 1, Col: g.NumberCol})
+		set(Food, game.Point{Row: g.NumberRow * 2, Col: g.NumberCol})
+	}
+	for a := 0; a < g.Players; a++ {
+		for _, p := range g.Body(a) {
+			o.Data[(Walls*rows+p.Row-1)*cols+p.Col-1] = 0
+			set(Channel(a, false), p)
+		}
+		set(Channel(a, true), game.Point{Row: g.Snakes[a].Row, Col: g.Snakes[a].Col})
+	}
+	return o
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
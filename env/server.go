@@ -0,0 +1,78 @@
+package env
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/gophun/nibbles/game"
+)
+
+// Request is a line sent to Serve.
+type Request struct {
+	Cmd     string           `json:"cmd"`               // "reset" or "step"
+	Seed    int64            `json:"seed,omitempty"`    // For reset
+	Config  *Config          `json:"config,omitempty"`  // For reset; keeps the previous configuration if missing
+	Actions []game.Direction `json:"actions,omitempty"` // For step
+}
+
+// Response is a line written by Serve.
+type Response struct {
+	Observation *Observation `json:"observation,omitempty"`
+	Reward      Reward       `json:"reward,omitempty"`
+	Done        bool         `json:"done,omitempty"`
+	Info        *Info        `json:"info,omitempty"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// Serve reads requests from r, one JSON object per line, and writes a
+// response line to w for each of them until r is exhausted. A reset
+// request answers with the first observation of the episode, a step
+// request with the result of Env.Step. Observation data is encoded in
+// base64. The games are played on levels, or on the original levels if
+// levels is nil.
+func Serve(r io.Reader, w io.Writer, levels []*game.Level) error {
+	e := New(DefaultConfig)
+	e.Config.Levels = levels
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	out := bufio.NewWriter(w)
+	enc := json.NewEncoder(out)
+	for scanner.Scan() {
+		var req Request
+		var resp Response
+		err := json.Unmarshal(scanner.Bytes(), &req)
+		switch {
+		case err != nil:
+			resp.Error = err.Error()
+		case req.Cmd == "reset":
+			if req.Config != nil {
+				e.Config = *req.Config
+				e.Config.Levels = levels
+			}
+			if e.Config.Players != 1 && e.Config.Players != 2 {
+				resp.Error = "players must be 1 or 2"
+				break
+			}
+			o := e.Reset(req.Seed)
+			resp.Observation = &o
+		case req.Cmd == "step":
+			if e.g == nil {
+				resp.Error = "step before reset"
+				break
+			}
+			o, reward, done, info := e.Step(req.Actions)
+			resp.Observation, resp.Reward, resp.Done, resp.Info = &o, reward, done, &info
+		default:
+			resp.Error = fmt.Sprintf("unknown command %q", req.Cmd)
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+		if err := out.Flush(); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
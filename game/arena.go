@@ -0,0 +1,169 @@
+package game
+
+// Size of the playing field of the original game in points. Each
+// character cell of the 80x25 text screen holds two points, one above the
+// other. Levels can have other sizes, see Level.
+const (
+	Rows = 50
+	Cols = 80
+)
+
+// Point is a position on the playing field. Rows and columns are numbered
+// from 1, row 0 means the point is not on the field.
+type Point struct {
+	Row int `json:"row"`
+	Col int `json:"col"`
+}
+
+// CellKind is the kind of thing at a point of the playing field.
+type CellKind uint8
+
+// Kinds of cells.
+const (
+	CellEmpty    CellKind = iota
+	CellWall              // A wall of the level, or put up by a trigger
+	CellObstacle          // A moving wall; Owner is the index of the obstacle
+	CellBody              // Owner is the index of the snake
+	CellHead              // Owner is the index of the snake
+	CellPortal            // Owner is the index of the portal
+	CellPlate             // A pressure plate, free to move onto
+	CellNumber            // Owner is the value of the number, free to move onto
+)
+
+// A Cell is what is at a point of the playing field.
+type Cell struct {
+	Kind  CellKind
+	Owner int
+}
+
+// Occupied reports whether a snake moving onto the cell dies.
+func (c Cell) Occupied() bool {
+	return c.Kind != CellEmpty && c.Kind != CellPlate && c.Kind != CellNumber
+}
+
+// Arena represents the playing field in memory. Each point stores the cell
+// at it and its floor, the cell it goes back to when freed: empty, or a
+// pressure plate. Points outside the field count as walls.
+type Arena struct {
+	rows, cols int
+	cells      []Cell
+	floor      []Cell
+
+	// ColorOf returns the color a cell is drawn in. OnSet is called with
+	// it after the cell at a point has changed its color, so the field can
+	// be drawn. OnResize is called after the size of the field has
+	// changed. OnSet and OnResize may be nil when playing without a
+	// screen.
+	ColorOf  func(c Cell) int
+	OnSet    func(row, col, color int)
+	OnResize func(rows, cols int)
+}
+
+// Resize sets the size of the playing field to rows by cols points and
+// clears it.
+func (a *Arena) Resize(rows, cols int) {
+	if rows != a.rows || cols != a.cols {
+		a.rows, a.cols = rows, cols
+		a.cells = make([]Cell, rows*cols)
+		a.floor = make([]Cell, rows*cols)
+		if a.OnResize != nil {
+			a.OnResize(rows, cols)
+		}
+	}
+	a.Clear()
+}
+
+// Size returns the number of rows and columns of the playing field.
+func (a *Arena) Size() (rows, cols int) {
+	return a.rows, a.cols
+}
+
+// Contains reports whether the given point is on the playing field.
+func (a *Arena) Contains(row, col int) bool {
+	return row >= 1 && row <= a.rows && col >= 1 && col <= a.cols
+}
+
+// Clear empties all points and their floors. OnSet is not called.
+func (a *Arena) Clear() {
+	for i := range a.cells {
+		a.cells[i] = Cell{}
+		a.floor[i] = Cell{}
+	}
+}
+
+// Put puts the cell at the given point.
+func (a *Arena) Put(row, col int, c Cell) {
+	if !a.Contains(row, col) {
+		return
+	}
+	i := (row-1)*a.cols + col - 1
+	old := a.cells[i]
+	a.cells[i] = c
+	if a.OnSet != nil && a.ColorOf(old) != a.ColorOf(c) {
+		a.OnSet(row, col, a.ColorOf(c))
+	}
+}
+
+// SetFloor sets the cell the given point goes back to when freed. A free
+// point gets the new floor at once.
+func (a *Arena) SetFloor(row, col int, c Cell) {
+	if !a.Contains(row, col) {
+		return
+	}
+	i := (row-1)*a.cols + col - 1
+	free := a.cells[i] == a.floor[i]
+	a.floor[i] = c
+	if free {
+		a.Put(row, col, c)
+	}
+}
+
+// Erase frees the given point, putting its floor there.
+func (a *Arena) Erase(row, col int) {
+	if !a.Contains(row, col) {
+		return
+	}
+	a.Put(row, col, a.floor[(row-1)*a.cols+col-1])
+}
+
+// Cell returns the cell at the given point; outside the field it is a
+// wall.
+func (a *Arena) Cell(row, col int) Cell {
+	if !a.Contains(row, col) {
+		return Cell{Kind: CellWall}
+	}
+	return a.cells[(row-1)*a.cols+col-1]
+}
+
+// Floor returns the cell the given point goes back to when freed.
+func (a *Arena) Floor(row, col int) Cell {
+	if !a.Contains(row, col) {
+		return Cell{Kind: CellWall}
+	}
+	return a.floor[(row-1)*a.cols+col-1]
+}
+
+// Color returns the color of the given point.
+func (a *Arena) Color(row, col int) int {
+	return a.ColorOf(a.Cell(row, col))
+}
+
+// PointIsThere reports whether the given point is occupied.
+func (a *Arena) PointIsThere(row, col int) bool {
+	if row == 0 {
+		return false
+	}
+	return a.Cell(row, col).Occupied()
+}
+
+// Sister returns the row of the point sharing a character cell with the
+// point in the given row.
+func Sister(row int) int {
+	return row + (row%2)*2 - 1
+}
+
+// RealRow returns the row of the text screen the point in the given row is
+// displayed in.
+func RealRow(row int) int {
+	return (row + 1) / 2
+}
@@ -0,0 +1,48 @@
+package game
+
+// A body holds the points a snake covers, from the end of its tail to its
+// head, in a ring buffer that doubles in size when it is full. Adding a
+// head and removing the end of the tail take constant time, so a snake
+// can grow until it fills the playing field. Whether a snake covers a
+// point is told by the cell of the arena at it, see Cell.
+type body struct {
+	points []Point
+	tail   int // Index of the end of the tail in points
+	n      int // Number of points
+}
+
+// Len returns the number of points of the body.
+func (b *body) Len() int {
+	return b.n
+}
+
+// At returns the i-th point of the body, counting from the head at 0.
+func (b *body) At(i int) Point {
+	return b.points[(b.tail+b.n-1-i)%len(b.points)]
+}
+
+// Push adds a new head.
+func (b *body) Push(p Point) {
+	if b.n == len(b.points) {
+		points := make([]Point, 2*len(b.points)+16)
+		for i := 0; i < b.n; i++ {
+			points[i] = b.points[(b.tail+i)%len(b.points)]
+		}
+		b.points, b.tail = points, 0
+	}
+	b.points[(b.tail+b.n)%len(b.points)] = p
+	b.n++
+}
+
+// Pop removes the end of the tail and returns it.
+func (b *body) Pop() Point {
+	p := b.points[b.tail]
+	b.tail = (b.tail + 1) % len(b.points)
+	b.n--
+	return p
+}
+
+// Reset removes all points.
+func (b *body) Reset() {
+	b.tail, b.n = 0, 0
+}
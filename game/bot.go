@@ -0,0 +1,162 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// A Bot steers a snake. Decide is called once per tick, before the snakes
+// move, and returns the direction the snake should head in. Turning back
+// on itself is ignored, unless Rules.TurnBack allows it, in which case the
+// snake runs into its own neck.
+type Bot interface {
+	Decide(s *State) Direction
+}
+
+// An Ender is a Bot that is shown how each round ends: End is called with
+// the state after the last move of the round, once a snake has died or the
+// level is complete.
+type Ender interface {
+	End(s *State)
+}
+
+// State is what a bot gets to see of the game.
+type State struct {
+	You     int          `json:"you"`     // Index of the bot's snake in Snakes
+	Mode    Mode         `json:"mode"`    // Kind of game played, see Game.Mode
+	Level   int          `json:"level"`   // Current level
+	Number  int          `json:"number"`  // Value of the current number
+	Food    []Point      `json:"food"`    // Points covered by the number, if any
+	Board   []string     `json:"board"`   // One string per row, see State
+	Wrap    Wrap         `json:"wrap"`    // Open edges of the map, see Move
+	Portals []Portal     `json:"portals"` // Linked pairs of points, see Move
+	Snakes  []SnakeState `json:"snakes"`
+}
+
+// SnakeState describes a snake for a bot.
+type SnakeState struct {
+	Body      []Point   `json:"body"` // Head first
+	Direction Direction `json:"direction"`
+	Lives     int       `json:"lives"`
+	Score     int       `json:"score"`
+	Alive     bool      `json:"alive"`
+}
+
+// Board characters. Snake points hold the number of the snake, starting at
+// '1'.
+const (
+	Free      = '.'
+	Wall      = '#'
+	PortalEnd = '@'
+)
+
+// State returns the state of the game as seen by the bot steering snake a.
+// The board has a string for each row of the playing field, with a
+// character for each column.
+func (g *Game) State(a int) *State {
+	rows, cols := g.Arena.Size()
+	board := make([][]byte, rows)
+	for row := range board {
+		board[row] = make([]byte, cols)
+		for col := range board[row] {
+			board[row][col] = Free
+			if g.Arena.PointIsThere(row+1, col+1) {
+				board[row][col] = Wall
+			}
+		}
+	}
+
+	s := &State{You: a, Mode: g.Mode, Level: g.CurLevel, Number: g.Number, Wrap: g.wrap, Portals: g.portals}
+	for _, portal := range g.portals {
+		for _, p := range portal {
+			board[p.Row-1][p.Col-1] = PortalEnd
+		}
+	}
+	if g.NumberRow != 0 {
+		s.Food = []Point{{g.NumberRow*2 - 1, g.NumberCol}, {g.NumberRow * 2, g.NumberCol}}
+	}
+	for b := 0; b < g.Players; b++ {
+		snake := g.Snakes[b]
+		ss := SnakeState{
+			Direction: snake.Direction,
+			Lives:     snake.Lives,
+			Score:     snake.Score,
+			Alive:     snake.Alive,
+		}
+		for _, p := range g.Body(b) {
+			ss.Body = append(ss.Body, p)
+			board[p.Row-1][p.Col-1] = byte('1' + b)
+		}
+		if len(ss.Body) == 0 {
+			// The snake has not moved yet
+			ss.Body = []Point{{snake.Row, snake.Col}}
+		}
+		s.Snakes = append(s.Snakes, ss)
+	}
+	for _, row := range board {
+		s.Board = append(s.Board, string(row))
+	}
+	return s
+}
+
+// Head returns the head of the bot's snake.
+func (s *State) Head() Point {
+	return s.Snakes[s.You].Body[0]
+}
+
+// Move returns the point one step from p in direction d, which is on the
+// opposite edge when leaving the map at an open edge, and beyond the
+// partner of a portal when stepping into one.
+func (s *State) Move(p Point, d Direction) Point {
+	return travel(p, d, s.Wrap, s.Portals, len(s.Board), len(s.Board[0]))
+}
+
+// IsFree reports whether a snake can move onto the given point without
+// dying.
+func (s *State) IsFree(p Point) bool {
+	if p.Row < 1 || p.Row > len(s.Board) || p.Col < 1 || p.Col > len(s.Board[0]) {
+		return false
+	}
+	return s.Board[p.Row-1][p.Col-1] == Free
+}
+
+var (
+	botsMu sync.Mutex
+	bots   = map[string]func() Bot{}
+)
+
+// Register makes a bot available under the given name. newBot is called
+// for every game the bot plays in. Register panics if the name is already
+// taken.
+func Register(name string, newBot func() Bot) {
+	botsMu.Lock()
+	defer botsMu.Unlock()
+	if _, dup := bots[name]; dup {
+		panic("game: Register called twice for bot " + name)
+	}
+	bots[name] = newBot
+}
+
+// NewBot creates a bot registered under the given name.
+func NewBot(name string) (Bot, error) {
+	botsMu.Lock()
+	newBot, ok := bots[name]
+	botsMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown bot %q", name)
+	}
+	return newBot(), nil
+}
+
+// Bots returns the sorted names of the registered bots.
+func Bots() []string {
+	botsMu.Lock()
+	defer botsMu.Unlock()
+	var names []string
+	for name := range bots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,190 @@
+package game
+
+import "math/rand"
+
+// Skill levels of the built-in computer player.
+const (
+	Novice = 1 + iota
+	Average
+	Expert
+)
+
+func init() {
+	Register("novice", func() Bot { return NewComputer(Novice) })
+	Register("average", func() Bot { return NewComputer(Average) })
+	Register("expert", func() Bot { return NewComputer(Expert) })
+}
+
+// Computer is the built-in computer player.
+//
+// A novice heads roughly for the number and makes the odd mistake. An
+// average player follows the shortest path to the number. An expert also
+// stays clear of the other snake's head and never moves into a region too
+// small to hold its snake.
+type Computer struct {
+	Skill int
+	rand  *rand.Rand
+}
+
+// NewComputer returns a computer player of the given skill.
+func NewComputer(skill int) *Computer {
+	return &Computer{Skill: skill, rand: rand.New(rand.NewSource(int64(skill)))}
+}
+
+var directions = []Direction{Up, Down, Left, Right}
+
+// Decide implements Bot.
+func (c *Computer) Decide(s *State) Direction {
+	me := s.Snakes[s.You]
+	head := s.Head()
+
+	// Collect the moves that do not kill the snake right away
+	var safe []Direction
+	for _, dir := range directions {
+		if dir != me.Direction.Opposite() && s.IsFree(s.Move(head, dir)) {
+			safe = append(safe, dir)
+		}
+	}
+	if len(safe) == 0 {
+		return me.Direction // Nothing helps anymore
+	}
+	if len(s.Food) == 0 {
+		return c.roomiest(s, safe, nil)
+	}
+
+	switch c.Skill {
+	case Novice:
+		if c.rand.Float64() < 0.1 {
+			return safe[c.rand.Intn(len(safe))]
+		}
+		best, bestDist := safe[0], 1<<30
+		for _, dir := range safe {
+			p := s.Move(head, dir)
+			dist := abs(p.Row-s.Food[0].Row) + abs(p.Col-s.Food[0].Col)
+			if dist < bestDist {
+				best, bestDist = dir, dist
+			}
+		}
+		return best
+	case Average:
+		if dir := PathToFood(s, nil); dir != 0 {
+			return dir
+		}
+		return safe[0]
+	}
+
+	var danger []Point
+	for b, snake := range s.Snakes {
+		if b == s.You || !snake.Alive {
+			continue
+		}
+		for _, dir := range directions {
+			danger = append(danger, s.Move(snake.Body[0], dir))
+		}
+	}
+	length := len(me.Body)
+	dir := PathToFood(s, danger)
+	if dir != 0 && FreeSpace(s, s.Move(head, dir), length) >= length {
+		return dir
+	}
+	return c.roomiest(s, safe, danger)
+}
+
+// roomiest returns the move leading into the largest free region, avoiding
+// the points in danger where possible.
+func (c *Computer) roomiest(s *State, safe []Direction, danger []Point) Direction {
+	best, bestSpace := safe[0], -1
+	for _, dir := range safe {
+		p := s.Move(s.Head(), dir)
+		space := FreeSpace(s, p, len(s.Board)*len(s.Board[0]))
+		for _, q := range danger {
+			if p == q {
+				space /= 2
+			}
+		}
+		if space > bestSpace {
+			best, bestSpace = dir, space
+		}
+	}
+	return best
+}
+
+// PathToFood searches the shortest path from the head of the bot's snake to
+// the number and returns the direction of its first step, or 0 if the
+// number cannot be reached. Points listed in avoid are not used for the
+// first step.
+func PathToFood(s *State, avoid []Point) Direction {
+	rows, cols := len(s.Board), len(s.Board[0])
+	first := make([]Direction, rows*cols) // Direction of the first step taken to reach a point
+	head := s.Head()
+	queue := make([]Point, 1, rows*cols)
+	queue[0] = head
+	for i := 0; i < len(queue); i++ {
+		p := queue[i]
+		for _, food := range s.Food {
+			if p == food {
+				return first[(p.Row-1)*cols+p.Col-1]
+			}
+		}
+		for _, dir := range directions {
+			if p == head && dir == s.Snakes[s.You].Direction.Opposite() {
+				continue
+			}
+			n := s.Move(p, dir)
+			if !s.IsFree(n) || first[(n.Row-1)*cols+n.Col-1] != 0 {
+				continue
+			}
+			if p == head {
+				if contains(avoid, n) {
+					continue
+				}
+				first[(n.Row-1)*cols+n.Col-1] = dir
+			} else {
+				first[(n.Row-1)*cols+n.Col-1] = first[(p.Row-1)*cols+p.Col-1]
+			}
+			queue = append(queue, n)
+		}
+	}
+	return 0
+}
+
+// FreeSpace counts the free points reachable from p, stopping once more than
+// limit points have been found.
+func FreeSpace(s *State, p Point, limit int) int {
+	if !s.IsFree(p) {
+		return 0
+	}
+	cols := len(s.Board[0])
+	seen := make([]bool, len(s.Board)*cols)
+	seen[(p.Row-1)*cols+p.Col-1] = true
+	count := 1
+	queue := []Point{p}
+	for i := 0; i < len(queue) && count <= limit; i++ {
+		p := queue[i]
+		for _, dir := range directions {
+			n := s.Move(p, dir)
+			if s.IsFree(n) && !seen[(n.Row-1)*cols+n.Col-1] {
+				seen[(n.Row-1)*cols+n.Col-1] = true
+				count++
+				queue = append(queue, n)
+			}
+		}
+	}
+	return count
+}
+
+func contains(points []Point, p Point) bool {
+	for _, q := range points {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,186 @@
+package game
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+)
+
+// External is a bot played by another program, which can be written in
+// any language. The program is started when the first move is asked for
+// and talks to the game over its standard input and output, one JSON
+// message per line.
+//
+// Before every move the game sends
+//
+//	{"tick": 1, "state": {...}}
+//
+// where state is the State as seen by the bot, and the program answers with
+//
+//	{"tick": 1, "move": "up"}
+//
+// naming one of the directions up, down, left or right. If no valid answer
+// for the tick arrives within Timeout, the snake keeps its direction. A
+// program that does not even read the message within Timeout is killed.
+// Once the program has exited or closed its output, or if it cannot be
+// started, the snake keeps its direction for the rest of the game.
+type External struct {
+	Timeout      time.Duration // Time allowed per move
+	StartTimeout time.Duration // Time allowed for the first move
+	Log          io.Writer     // Receives errors and the program's standard error; may be nil
+
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	answers chan externalMessage
+	done    chan struct{}
+	tick    int
+	killed  bool // The program did not read its input in time
+	closed  bool
+}
+
+type externalMessage struct {
+	Tick  int    `json:"tick"`
+	State *State `json:"state,omitempty"`
+	Move  string `json:"move,omitempty"`
+}
+
+// NewExternal returns a bot played by the program with the given
+// arguments. Errors are written to log, which may be nil.
+func NewExternal(log io.Writer, name string, args ...string) *External {
+	return &External{
+		Timeout:      100 * time.Millisecond,
+		StartTimeout: time.Second,
+		Log:          log,
+		cmd:          exec.Command(name, args...),
+		answers:      make(chan externalMessage, 1),
+		done:         make(chan struct{}),
+	}
+}
+
+// start starts the program.
+func (x *External) start() error {
+	x.cmd.Stderr = x.Log
+	var err error
+	if x.stdin, err = x.cmd.StdinPipe(); err != nil {
+		return err
+	}
+	stdout, err := x.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := x.cmd.Start(); err != nil {
+		return err
+	}
+	go x.read(stdout)
+	return nil
+}
+
+// read passes the answers of the program to Decide until the program
+// closes its output.
+func (x *External) read(stdout io.Reader) {
+	defer close(x.answers)
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		var answer externalMessage
+		if err := json.Unmarshal(scanner.Bytes(), &answer); err != nil {
+			x.logf("invalid answer: %v", err)
+			continue
+		}
+		select {
+		case x.answers <- answer:
+		case <-x.done:
+			return
+		}
+	}
+}
+
+// Decide implements Bot.
+func (x *External) Decide(s *State) Direction {
+	fallback := s.Snakes[s.You].Direction
+	x.tick++
+	timeout := x.Timeout
+	if x.tick == 1 {
+		timeout = x.StartTimeout
+		if err := x.start(); err != nil {
+			x.logf("%v", err)
+			return fallback
+		}
+	}
+	if x.cmd.Process == nil || x.killed {
+		return fallback // Could not be started, or stopped reading
+	}
+
+	msg, err := json.Marshal(externalMessage{Tick: x.tick, State: s})
+	if err != nil {
+		panic(err)
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	// Writing blocks once the pipe is full if the program stops reading
+	written := make(chan error, 1)
+	go func() {
+		_, err := x.stdin.Write(append(msg, '\n'))
+		written <- err
+	}()
+	select {
+	case err := <-written:
+		if err != nil {
+			return fallback
+		}
+	case <-timer.C:
+		x.logf("tick %d: input not read within %v", x.tick, timeout)
+		x.killed = true
+		x.cmd.Process.Kill()
+		return fallback
+	}
+
+	for {
+		select {
+		case answer, ok := <-x.answers:
+			if !ok {
+				return fallback
+			}
+			if answer.Tick != x.tick {
+				continue // Too late for an earlier tick
+			}
+			dir, ok := directionNames[answer.Move]
+			if !ok {
+				x.logf("tick %d: invalid move %q", x.tick, answer.Move)
+				return fallback
+			}
+			return dir
+		case <-timer.C:
+			x.logf("tick %d: no answer within %v", x.tick, timeout)
+			return fallback
+		}
+	}
+}
+
+// Close stops the program. Closing a bot more than once does nothing.
+func (x *External) Close() error {
+	if x.cmd.Process == nil || x.closed {
+		return nil
+	}
+	x.closed = true
+	close(x.done)
+	x.stdin.Close()
+	done := make(chan error, 1)
+	go func() { done <- x.cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(x.Timeout):
+		x.cmd.Process.Kill()
+		return <-done
+	}
+}
+
+func (x *External) logf(format string, a ...interface{}) {
+	if x.Log != nil {
+		fmt.Fprintf(x.Log, "%s: "+format+"\n", append([]interface{}{x.cmd.Path}, a...)...)
+	}
+}
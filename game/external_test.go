@@ -0,0 +1,69 @@
+package game
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestExternalHelper is not a test, but the bot program started by the
+// tests of External. NIBBLES_TEST_BOT says how it misbehaves.
+func TestExternalHelper(t *testing.T) {
+	behavior := os.Getenv("NIBBLES_TEST_BOT")
+	if behavior == "" {
+		return
+	}
+	defer os.Exit(0)
+	if behavior == "exit" {
+		return
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var msg externalMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+		switch {
+		case behavior == "garbage":
+			fmt.Println("down, please")
+			fmt.Printf("{\"tick\": %d, \"move\": \"sideways\"}\n", msg.Tick)
+			continue
+		case behavior == "slow" && msg.Tick > 1:
+			time.Sleep(200 * time.Millisecond)
+		}
+		fmt.Printf("{\"tick\": %d, \"move\": \"down\"}\n", msg.Tick)
+	}
+}
+
+func TestExternal(t *testing.T) {
+	tests := []struct {
+		behavior string
+		want     []Direction // Moves for the first ticks
+	}{
+		{"good", []Direction{Down, Down, Down}},
+		{"slow", []Direction{Down, Left, Left}},
+		{"garbage", []Direction{Left, Left, Left}},
+		{"exit", []Direction{Left, Left, Left}},
+	}
+	for _, test := range tests {
+		t.Run(test.behavior, func(t *testing.T) {
+			t.Setenv("NIBBLES_TEST_BOT", test.behavior)
+			x := NewExternal(nil, os.Args[0], "-test.run=^TestExternalHelper$")
+			x.Timeout = 50 * time.Millisecond
+			x.StartTimeout = 10 * time.Second
+			defer x.Close()
+
+			s := &State{Snakes: []SnakeState{{Direction: Left}}}
+			for tick, want := range test.want {
+				if got := x.Decide(s); got != want {
+					t.Errorf("tick %d: move %v, want %v", tick+1, got, want)
+				}
+			}
+		})
+	}
+}
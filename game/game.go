@@ -0,0 +1,513 @@
+// Package game implements the rules of Nibbles without any display, so the
+// game can be played on a terminal as well as by programs.
+package game
+
+import (
+	"math/rand"
+	"strconv"
+)
+
+// Parameters to Level method
+const (
+	StartOver = 1 + iota
+	SameLevel
+	NextLevel
+)
+
+// Direction a snake is heading in.
+type Direction int
+
+const (
+	Up Direction = 1 + iota
+	Down
+	Left
+	Right
+)
+
+// Opposite returns the direction a snake heading in direction d cannot
+// turn to.
+func (d Direction) Opposite() Direction {
+	switch d {
+	case Up:
+		return Down
+	case Down:
+		return Up
+	case Left:
+		return Right
+	}
+	return Left
+}
+
+// Step returns the point next to p in direction d.
+func (d Direction) Step(p Point) Point {
+	switch d {
+	case Up:
+		p.Row--
+	case Down:
+		p.Row++
+	case Left:
+		p.Col--
+	case Right:
+		p.Col++
+	}
+	return p
+}
+
+// Snake is the state of a player's snake.
+type Snake struct {
+	Length    int // Number of points the snake grows to
+	Row       int
+	Col       int
+	Direction Direction
+	Lives     int
+	Score     int
+	Eaten     int // Points of Score scored by eating numbers
+	Color     int
+	Alive     bool
+
+	// Cause is what killed the snake when it last died, and Killer the
+	// snake it ran into for HitSelf, HitOther and HeadOn.
+	Cause  Cause
+	Killer int
+	Kills  int         // Times the other snake died running into this one
+	Deaths [causes]int // Number of deaths by cause
+}
+
+// Cause is what a snake died of.
+type Cause int
+
+// Causes of death.
+const (
+	HitWall  Cause = 1 + iota // A wall, obstacle or portal
+	HitSelf                   // Its own body
+	HitOther                  // The body of the other snake
+	HeadOn                    // The other snake moving onto the same point
+	causes
+)
+
+var causeTexts = [causes]string{
+	HitWall:  "wall",
+	HitSelf:  "own body",
+	HitOther: "other snake",
+	HeadOn:   "head-on",
+}
+
+// String describes the cause, like "wall" or "head-on".
+func (c Cause) String() string {
+	if c < HitWall || c >= causes {
+		return "Cause(" + strconv.Itoa(int(c)) + ")"
+	}
+	return causeTexts[c]
+}
+
+// Causes returns all causes of death, in order.
+func Causes() []Cause {
+	return []Cause{HitWall, HitSelf, HitOther, HeadOn}
+}
+
+// Game holds the state of a game of Nibbles.
+type Game struct {
+	Arena    Arena
+	Snakes   []Snake
+	Players  int
+	Levels   []*Level
+	CurLevel int
+
+	// StartLevel is the level a game starts over at, level 1 if 0.
+	StartLevel int
+
+	// Rules are the rules the game is played by, Classic unless set
+	// with SetRules.
+	Rules Rules
+
+	// Mode is the kind of game played. In Tron, a round ends when a snake
+	// dies, and each snake left alive scores a point; there are no other
+	// points to win or lose. In Survival and Endless, the levels never
+	// end, and in Survival a snake's first death is its last. In CoOp,
+	// the snakes share the lives of all of them: every death costs the team
+	// a life, and the game goes on while there are lives left.
+	Mode Mode
+
+	// Borderless removes the walls on the edges of the levels and opens
+	// all edges, see Wrap.
+	Borderless bool
+
+	// PortalColor is the color of the portals, see Portal, and PlateColor
+	// that of the pressure plates, see Trigger.
+	PortalColor int
+	PlateColor  int
+
+	// GenerateLevels makes the levels beyond the last one of Levels
+	// generated levels instead of repeats of the last one. LevelSeed
+	// selects the series of generated levels, see GeneratedLevel, and
+	// LevelRows and LevelCols their size, Rows by Cols if 0.
+	GenerateLevels bool
+	LevelSeed      int64
+	LevelRows      int
+	LevelCols      int
+
+	// Current number that snakes are trying to run into, and its position
+	// on the text screen. NumberRow is 0 while no number is on the screen.
+	Number    int
+	NumberRow int
+	NumberCol int
+
+	wallColor  int
+	background int
+	wrap       Wrap // Open edges of the current level
+	portals    []Portal
+	obstacles  []obstacle
+	triggers   []Trigger
+	closing    []Point // Walls of triggers waiting to be put up
+	tick       int     // Moves since the level started
+	bodies     [2]body
+	rand       *rand.Rand
+	generated  map[int]*Level
+}
+
+// Events reports what happened during a Step.
+type Events struct {
+	Ate           bool `json:"ate"`            // A snake ran into the number
+	LevelComplete bool `json:"level_complete"` // The last number of the level was eaten
+	Died          bool `json:"died"`           // A snake died; see Snake.Alive
+}
+
+// New creates a game for one or two players on the levels of the original
+// game, followed by generated levels. colors holds the colors of snake 1,
+// snake 2, walls and background, and seed seeds the placement of numbers.
+// Call Level to set up the playing field.
+func New(players int, colors []int, seed int64) *Game {
+	g := &Game{
+		Players: players,
+		Levels:  DefaultLevels(),
+		Snakes:  make([]Snake, 2),
+		rand:    rand.New(rand.NewSource(seed)),
+
+		GenerateLevels: true,
+		LevelSeed:      seed,
+	}
+	g.background = colors[3]
+	g.wallColor = colors[2]
+	g.Arena.ColorOf = g.color
+	g.Arena.Resize(Rows, Cols)
+	g.PortalColor = 11
+	g.PlateColor = 10
+	for a := range g.Snakes {
+		g.Snakes[a].Score = 0
+		g.Snakes[a].Color = colors[a]
+	}
+	g.SetRules(Classic)
+	return g
+}
+
+// SetRules sets the rules of a game that has not started yet and gives the
+// snakes their lives. In CoOp, each snake holds the pool of the lives of
+// all players, so the Mode is set first.
+func (g *Game) SetRules(r Rules) {
+	g.Rules = r
+	lives := r.Lives
+	if g.Mode == CoOp {
+		lives *= g.Players
+	}
+	for a := range g.Snakes {
+		g.Snakes[a].Lives = lives
+	}
+}
+
+// Over reports whether one of the snakes has run out of lives, or in
+// CoOp all of them.
+func (g *Game) Over() bool {
+	if g.Mode == CoOp {
+		return g.Snakes[0].Lives <= 0 && g.Snakes[1].Lives <= 0
+	}
+	return g.Snakes[0].Lives <= 0 || g.Snakes[1].Lives <= 0
+}
+
+// TeamScore returns the combined score of the players.
+func (g *Game) TeamScore() int {
+	score := 0
+	for a := 0; a < g.Players; a++ {
+		score += g.Snakes[a].Score
+	}
+	return score
+}
+
+// Turn changes the direction of snake a unless that would make it turn
+// back on itself and the rules do not allow that.
+func (g *Game) Turn(a int, direction Direction) {
+	if g.Rules.TurnBack || g.Snakes[a].Direction != direction.Opposite() {
+		g.Snakes[a].Direction = direction
+	}
+}
+
+// PlaceNumber puts the current number at a random free place if there is
+// no number on the screen. It reports whether a number was placed, which
+// it is not if the field has no free place left.
+func (g *Game) PlaceNumber() bool {
+	if g.NumberRow != 0 || g.Mode == Tron {
+		return false
+	}
+	// Numbers keep off the edges, unless they are open
+	rows, cols := g.Arena.Size()
+	lastRow, firstCol, lastCol := rows-1, 2, cols-1
+	if g.wrap&WrapVertical != 0 {
+		lastRow = rows
+	}
+	if g.wrap&WrapHorizontal != 0 {
+		firstCol, lastCol = 1, cols
+	}
+	var places []Point
+	for row := FirstMapRow; row <= lastRow; row++ {
+		for col := firstCol; col <= lastCol; col++ {
+			// Pressure plates stay visible
+			if g.Arena.Cell(row, col).Kind == CellEmpty && g.Arena.Cell(Sister(row), col).Kind == CellEmpty {
+				places = append(places, Point{row, col})
+			}
+		}
+	}
+	if len(places) == 0 {
+		return false
+	}
+	p := places[g.rand.Intn(len(places))]
+	g.NumberRow = RealRow(p.Row)
+	g.NumberCol = p.Col
+	g.Arena.Put(p.Row, p.Col, Cell{CellNumber, g.Number})
+	g.Arena.Put(Sister(p.Row), p.Col, Cell{CellNumber, g.Number})
+	return true
+}
+
+// color returns the color a cell is drawn in.
+func (g *Game) color(c Cell) int {
+	switch c.Kind {
+	case CellWall, CellObstacle:
+		return g.wallColor
+	case CellBody, CellHead:
+		return g.Snakes[c.Owner].Color
+	case CellPortal:
+		return g.PortalColor
+	case CellPlate:
+		return g.PlateColor
+	}
+	return g.background
+}
+
+// Step moves the snakes one point ahead. All snakes move at the same time:
+// first their heads move, then the snakes that hit the number eat it, and
+// then the snakes that ran into any point, or the head of the other snake,
+// die, and the others leave the end of their tails behind. Ties decides
+// the cases where the order would matter, see Rules. Once the level is
+// complete or a snake has died, the caller must call Level before the next
+// Step. Both can happen in the same move.
+func (g *Game) Step() Events {
+	var ev Events
+	g.tick++
+	g.moveObstacles()
+	g.fire(OnTime, g.tick, Point{})
+	g.closeWalls()
+
+	// Move the heads
+	for a := 0; a < g.Players; a++ {
+		s := &g.Snakes[a]
+		p := g.Move(Point{s.Row, s.Col}, s.Direction)
+		s.Row, s.Col = p.Row, p.Col
+	}
+
+	// If snakes hit the number, respond accordingly
+	var eaters []int
+	for a := 0; a < g.Players; a++ {
+		if s := &g.Snakes[a]; g.NumberRow == RealRow(s.Row) && g.NumberCol == s.Col {
+			eaters = append(eaters, a)
+		}
+	}
+	if len(eaters) > 0 {
+		ev.Ate = true
+		g.fire(OnNumber, g.Number, Point{})
+		points := g.Number
+		if len(eaters) > 1 && g.Rules.Ties.Number == SplitNumber {
+			points = (points + 1) / 2
+		}
+		for _, a := range eaters {
+			s := &g.Snakes[a]
+			s.Length = s.Length + g.Number*g.Rules.Growth
+			s.Score = s.Score + points
+			s.Eaten += points
+		}
+		g.Arena.Erase(g.NumberRow*2-1, g.NumberCol)
+		g.Arena.Erase(g.NumberRow*2, g.NumberCol)
+		g.Number++
+		g.NumberRow = 0
+		if g.Number > g.Numbers() {
+			if g.Mode.Loops() {
+				g.Number = 1
+			} else {
+				ev.LevelComplete = true
+			}
+		}
+	}
+
+	// If a player runs into any point, or the head of the other snake, it
+	// dies. All collisions are found before any snake moves on; a death can
+	// lead to another, as the tail of a snake that dies stays in place.
+	var died [2]Cause
+	var killers [2]int
+	for more := true; more; {
+		more = false
+		for a := 0; a < g.Players; a++ {
+			if died[a] == 0 {
+				died[a], killers[a] = g.collision(a, died)
+				more = more || died[a] != 0
+			}
+		}
+	}
+	for a := 0; a < g.Players; a++ {
+		s := &g.Snakes[a]
+		if cause, killer := died[a], killers[a]; cause != 0 {
+			ev.Died = true
+			s.Alive = false
+			s.Lives = s.Lives - 1
+			if g.Mode == Survival {
+				s.Lives = 0
+			}
+			if g.Mode == CoOp {
+				g.shareLives(a)
+			}
+			if g.Mode != Tron {
+				s.Score -= g.Rules.Penalty
+			}
+			s.Cause, s.Killer = cause, killer
+			s.Deaths[cause]++
+			if cause == HitOther || cause == HeadOn {
+				g.Snakes[killer].Kills++
+				if g.Mode != Tron && g.Mode != CoOp {
+					g.Snakes[killer].Score += g.Rules.KillPoints
+				}
+			}
+
+			// Otherwise, erase the tail
+		} else if tail, ok := g.tail(a); ok {
+			g.bodies[a].Pop()
+			g.Arena.Erase(tail.Row, tail.Col)
+		}
+	}
+	// and move the snakes that survived, once all tails are gone
+	for a := 0; a < g.Players; a++ {
+		s := &g.Snakes[a]
+		if !s.Alive {
+			continue
+		}
+		b := &g.bodies[a]
+		if b.Len() > 0 {
+			neck := b.At(0)
+			g.Arena.Put(neck.Row, neck.Col, Cell{CellBody, a})
+		}
+		b.Push(Point{s.Row, s.Col})
+		g.Arena.Put(s.Row, s.Col, Cell{CellHead, a})
+	}
+	// Pressure plates go off once all snakes are in place
+	for a := 0; a < g.Players; a++ {
+		if s := g.Snakes[a]; s.Alive {
+			g.fire(OnPlate, 0, Point{s.Row, s.Col})
+		}
+	}
+	// The survivors win the round
+	if ev.Died && g.Mode == Tron {
+		for a := 0; a < g.Players; a++ {
+			if g.Snakes[a].Alive {
+				g.Snakes[a].Score++
+			}
+		}
+	}
+	return ev
+}
+
+// Numbers returns the number of numbers to eat to complete the current
+// level.
+func (g *Game) Numbers() int {
+	if g.Rules.Numbers > 0 {
+		return g.Rules.Numbers
+	}
+	return g.CurrentLevel().Numbers
+}
+
+// shareLives takes the life snake a lost from the pool of the team, which
+// all snakes hold in CoOp.
+func (g *Game) shareLives(a int) {
+	if g.Snakes[a].Lives < 0 {
+		g.Snakes[a].Lives = 0 // Both died with one life left
+	}
+	for b := range g.Snakes {
+		if b != a {
+			g.Snakes[b].Lives = g.Snakes[a].Lives
+		}
+	}
+}
+
+// tail returns the point the end of the tail of snake a leaves when the
+// snake moves on. ok is false if the snake is still growing, or leaves a
+// trail in Tron.
+func (g *Game) tail(a int) (p Point, ok bool) {
+	b := &g.bodies[a]
+	if g.Mode == Tron || b.Len() == 0 || b.Len() < g.Snakes[a].Length {
+		return Point{}, false
+	}
+	return b.At(b.Len() - 1), true
+}
+
+// collision returns what snake a dies of at the point its head has moved
+// to and the snake it runs into, or 0 if it survives. died holds the
+// snakes already known to die in this move.
+func (g *Game) collision(a int, died [2]Cause) (Cause, int) {
+	s := g.Snakes[a]
+	head := Point{s.Row, s.Col}
+	other := 1 - a
+	o := g.Snakes[other]
+	if o.Row == s.Row && o.Col == s.Col || g.swapped(a, other) {
+		if g.Rules.Ties.HeadOn == LongerSurvives && s.Length > o.Length {
+			return 0, 0
+		}
+		return HeadOn, other
+	}
+	c := g.Arena.Cell(s.Row, s.Col)
+	switch {
+	case !c.Occupied():
+		return 0, 0
+	case c.Kind != CellBody && c.Kind != CellHead:
+		return HitWall, 0
+	}
+	if tail, ok := g.tail(c.Owner); ok && g.Rules.Ties.TailChase && head == tail && died[c.Owner] == 0 {
+		return 0, 0
+	}
+	if c.Owner == a {
+		return HitSelf, a
+	}
+	return HitOther, c.Owner
+}
+
+// swapped reports whether the heads of snakes a and b have just moved
+// through each other, each onto the neck of the other.
+func (g *Game) swapped(a, b int) bool {
+	if g.bodies[a].Len() == 0 || g.bodies[b].Len() == 0 {
+		return false
+	}
+	na, nb := g.bodies[a].At(0), g.bodies[b].At(0)
+	sa, sb := g.Snakes[a], g.Snakes[b]
+	return sa.Row == nb.Row && sa.Col == nb.Col && sb.Row == na.Row && sb.Col == na.Col
+}
+
+// Move returns the point one step from p in direction d on the current
+// level, through its open edges and portals.
+func (g *Game) Move(p Point, d Direction) Point {
+	rows, cols := g.Arena.Size()
+	return travel(p, d, g.wrap, g.portals, rows, cols)
+}
+
+// Body returns the points of snake a, starting with its head. While the
+// snake is growing, it covers fewer points than its Length.
+func (g *Game) Body(a int) []Point {
+	b := &g.bodies[a]
+	body := make([]Point, b.Len())
+	for i := range body {
+		body[i] = b.At(i)
+	}
+	return body
+}
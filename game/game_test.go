@@ -0,0 +1,177 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+const openLevel = `name: Open
+directions: right left
+map:
+############
+#..........#
+#..........#
+#.1......2.#
+#..........#
+#..........#
+#..........#
+############
+`
+
+// tieGame returns a game on an open level with snake 1 at row1, col1 and
+// snake 2 at row2, col2, heading in the given directions, played by rules
+// with the given ties.
+func tieGame(t *testing.T, ties Ties, row1, col1 int, dir1 Direction, row2, col2 int, dir2 Direction) *Game {
+	t.Helper()
+	lvl, err := ParseLevel("open.txt", strings.NewReader(openLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := New(2, DefaultColors, 1)
+	rules := Classic
+	rules.Ties = ties
+	g.SetRules(rules)
+	g.Levels = []*Level{lvl}
+	g.GenerateLevels = false
+	g.Level(StartOver)
+	g.Snakes[0].Row, g.Snakes[0].Col, g.Snakes[0].Direction = row1, col1, dir1
+	g.Snakes[1].Row, g.Snakes[1].Col, g.Snakes[1].Direction = row2, col2, dir2
+	return g
+}
+
+// steps moves the snakes n times and returns the events of the last move.
+func steps(t *testing.T, g *Game, n int) Events {
+	t.Helper()
+	var ev Events
+	for i := 1; i <= n; i++ {
+		if ev = g.Step(); i < n && (ev.Died || ev.Ate) {
+			t.Fatalf("move %d: %+v before the tie", i, ev)
+		}
+	}
+	return ev
+}
+
+func TestNumberTie(t *testing.T) {
+	tests := []struct {
+		tie  NumberTie
+		want int // Points for each snake for a 5
+	}{
+		{SplitNumber, 3},
+		{ShareNumber, 5},
+	}
+	for _, test := range tests {
+		// The snakes reach the two points of the number at once
+		g := tieGame(t, Ties{Number: test.tie}, 5, 3, Right, 6, 7, Left)
+		g.Number = 5
+		g.NumberRow, g.NumberCol = RealRow(5), 5
+		g.Arena.Put(5, 5, Cell{CellNumber, g.Number})
+		g.Arena.Put(6, 5, Cell{CellNumber, g.Number})
+
+		ev := steps(t, g, 2)
+		if !ev.Ate || ev.Died {
+			t.Fatalf("%v: events %+v, want the number eaten", test.tie, ev)
+		}
+		for a := 0; a < 2; a++ {
+			if got := g.Snakes[a].Score; got != test.want {
+				t.Errorf("%v: snake %d scored %d, want %d", test.tie, a+1, got, test.want)
+			}
+			if got, want := g.Snakes[a].Length, 2+5*g.Rules.Growth; got != want {
+				t.Errorf("%v: snake %d grows to %d, want %d", test.tie, a+1, got, want)
+			}
+		}
+	}
+}
+
+func TestHeadOnTie(t *testing.T) {
+	tests := []struct {
+		tie     HeadOnTie
+		col2    int     // Start of snake 2: 7 to meet at column 5, 6 to swap places
+		length1 int     // Length of snake 1; snake 2 is 2 long
+		alive   [2]bool // Snakes alive after the collision
+	}{
+		{BothDie, 7, 2, [2]bool{false, false}},
+		{BothDie, 7, 10, [2]bool{false, false}},
+		{LongerSurvives, 7, 2, [2]bool{false, false}},
+		{LongerSurvives, 7, 10, [2]bool{true, false}},
+		{BothDie, 6, 10, [2]bool{false, false}},
+		{LongerSurvives, 6, 2, [2]bool{false, false}},
+		{LongerSurvives, 6, 10, [2]bool{true, false}},
+	}
+	for _, test := range tests {
+		g := tieGame(t, Ties{HeadOn: test.tie}, 5, 3, Right, 5, test.col2, Left)
+		g.Snakes[0].Length = test.length1
+
+		ev := steps(t, g, 2)
+		if !ev.Died {
+			t.Fatalf("%v, column %d, length %d: no one died", test.tie, test.col2, test.length1)
+		}
+		for a := 0; a < 2; a++ {
+			s := g.Snakes[a]
+			if s.Alive != test.alive[a] {
+				t.Errorf("%v, column %d, length %d: snake %d alive %v, want %v", test.tie, test.col2, test.length1, a+1, s.Alive, test.alive[a])
+			}
+			if !s.Alive && (s.Cause != HeadOn || s.Killer != 1-a) {
+				t.Errorf("%v, column %d, length %d: snake %d died of %v by %d, want head-on by %d", test.tie, test.col2, test.length1, a+1, s.Cause, s.Killer, 1-a)
+			}
+		}
+	}
+}
+
+func TestTailChase(t *testing.T) {
+	tests := []struct {
+		tailChase bool
+		wall      bool // Snake 2 runs into a wall in the same move
+		alive     bool // Snake 1 alive after moving onto the tail of snake 2
+	}{
+		{false, false, false},
+		{true, false, true},
+		{true, true, false}, // The tail of a snake that dies stays
+	}
+	for _, test := range tests {
+		// Snake 1 follows right behind snake 2 once both are 2 points long
+		g := tieGame(t, Ties{TailChase: test.tailChase}, 5, 2, Right, 5, 4, Right)
+		if test.wall {
+			g.Arena.Put(5, 7, Cell{Kind: CellWall})
+		}
+
+		ev := steps(t, g, 3)
+		if s := g.Snakes[0]; s.Alive != test.alive {
+			t.Errorf("tail chase %v, wall %v: snake 1 alive %v, want %v (died of %v)", test.tailChase, test.wall, s.Alive, test.alive, s.Cause)
+		}
+		if !test.alive && g.Snakes[0].Cause != HitOther {
+			t.Errorf("tail chase %v, wall %v: snake 1 died of %v, want %v", test.tailChase, test.wall, g.Snakes[0].Cause, HitOther)
+		}
+		if test.wall && g.Snakes[1].Cause != HitWall {
+			t.Errorf("tail chase %v, wall %v: snake 2 died of %v, want %v", test.tailChase, test.wall, g.Snakes[1].Cause, HitWall)
+		}
+		if test.alive {
+			if ev.Died {
+				t.Errorf("tail chase %v, wall %v: events %+v, want no deaths", test.tailChase, test.wall, ev)
+			}
+			if c := g.Arena.Cell(5, 5); c.Kind != CellHead || c.Owner != 0 {
+				t.Errorf("tail chase %v, wall %v: cell of the old tail %+v, want the head of snake 1", test.tailChase, test.wall, c)
+			}
+		}
+	}
+}
+
+func TestDeathOnLevelComplete(t *testing.T) {
+	// Snake 1 eats the last number as snake 2 runs into the top wall
+	g := tieGame(t, Ties{}, 5, 3, Right, 5, 8, Up)
+	g.Number = g.Numbers()
+	g.NumberRow, g.NumberCol = RealRow(5), 5
+	g.Arena.Put(5, 5, Cell{CellNumber, g.Number})
+	g.Arena.Put(6, 5, Cell{CellNumber, g.Number})
+	lives := g.Snakes[1].Lives
+
+	ev := steps(t, g, 2)
+	if !ev.LevelComplete || !ev.Died {
+		t.Fatalf("events %+v, want the level complete and a death", ev)
+	}
+	if s := g.Snakes[0]; !s.Alive {
+		t.Errorf("snake 1 died of %v, want it alive", s.Cause)
+	}
+	if s := g.Snakes[1]; s.Alive || s.Cause != HitWall || s.Lives != lives-1 {
+		t.Errorf("snake 2 alive %v, died of %v with %d lives, want it dead of %v with %d", s.Alive, s.Cause, s.Lives, HitWall, lives-1)
+	}
+}
@@ -0,0 +1,338 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Styles of generated levels.
+const (
+	Pillars   = "pillars"
+	Maze      = "maze"
+	Rooms     = "rooms"
+	Corridors = "corridors"
+)
+
+// Styles lists the styles of generated levels.
+var Styles = []string{Pillars, Maze, Rooms, Corridors}
+
+// GenOptions control the generation of a level.
+type GenOptions struct {
+	Seed    int64
+	Style   string  // One of Styles
+	Density float64 // Share of the field covered by walls, up to 0.5
+
+	// Size of the playing field, Rows by Cols if 0, and at least
+	// MinGenRows by MinGenCols.
+	Rows, Cols int
+}
+
+// Smallest playing field of generated levels.
+const (
+	MinGenRows = 24
+	MinGenCols = 40
+)
+
+// Generate creates a level from the options. Generated levels are
+// surrounded by a wall and look the same when rotated by 180 degrees,
+// including the starts of the players, so that both players have the same
+// chances. They are free of validation errors. The same options always
+// create the same level.
+func Generate(opt GenOptions) *Level {
+	if opt.Density > 0.5 {
+		opt.Density = 0.5
+	}
+	if opt.Rows == 0 {
+		opt.Rows = Rows
+	}
+	if opt.Cols == 0 {
+		opt.Cols = Cols
+	}
+	if opt.Rows < MinGenRows {
+		opt.Rows = MinGenRows
+	}
+	opt.Rows += opt.Rows % 2 // The map needs an even number of lines
+	if opt.Cols < MinGenCols {
+		opt.Cols = MinGenCols
+	}
+	r := rand.New(rand.NewSource(opt.Seed))
+	for try := 0; try < 100; try++ {
+		lvl := generate(r, opt)
+		if !hasErrors(lvl.Validate()) {
+			return lvl
+		}
+	}
+	// Give up on walls inside the field
+	lvl := generate(r, GenOptions{Seed: opt.Seed, Style: opt.Style, Rows: opt.Rows, Cols: opt.Cols})
+	lvl.Name = fmt.Sprintf("%s %d", opt.Style, opt.Seed)
+	return lvl
+}
+
+// GeneratedLevel returns the nth level of an endless series of generated
+// levels of the given size, which cycles through the styles and gets
+// denser the further it goes.
+func GeneratedLevel(seed int64, n, rows, cols int) *Level {
+	density := 0.1 + 0.02*float64(n/len(Styles))
+	if density > 0.35 {
+		density = 0.35
+	}
+	return Generate(GenOptions{
+		Seed:    seed*7919 + int64(n),
+		Style:   Styles[n%len(Styles)],
+		Density: density,
+		Rows:    rows,
+		Cols:    cols,
+	})
+}
+
+func hasErrors(problems []Problem) bool {
+	for _, p := range problems {
+		if p.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// field is the playing field of a level being generated.
+type field struct {
+	wall       grid
+	rows, cols int
+
+	// Bounds of the field inside the border
+	top, bottom, left, right int
+}
+
+func newField(rows, cols int) *field {
+	return &field{
+		wall: newGrid(rows, cols),
+		rows: rows,
+		cols: cols,
+
+		top:    FirstMapRow + 1,
+		bottom: rows - 1,
+		left:   2,
+		right:  cols - 1,
+	}
+}
+
+// rotate returns the point opposite p when the field is rotated by 180
+// degrees.
+func (f *field) rotate(p Point) Point {
+	return Point{FirstMapRow + f.rows - p.Row, f.cols + 1 - p.Col}
+}
+
+func (f *field) set(row, col int, wall bool) {
+	if row >= f.top && row <= f.bottom && col >= f.left && col <= f.right {
+		f.wall[row][col] = wall
+	}
+}
+
+// rect sets the walls of the rectangle with the given corners.
+func (f *field) rect(row1, col1, row2, col2 int, wall bool) {
+	for row := row1; row <= row2; row++ {
+		for col := col1; col <= col2; col++ {
+			f.set(row, col, wall)
+		}
+	}
+}
+
+// density returns the share of the field inside the border covered by
+// walls.
+func (f *field) density() float64 {
+	n := 0
+	for row := f.top; row <= f.bottom; row++ {
+		for col := f.left; col <= f.right; col++ {
+			if f.wall[row][col] {
+				n++
+			}
+		}
+	}
+	return float64(n) / float64((f.bottom-f.top+1)*(f.right-f.left+1))
+}
+
+func generate(r *rand.Rand, opt GenOptions) *Level {
+	f := newField(opt.Rows, opt.Cols)
+	top, bottom, left, right := f.top, f.bottom, f.left, f.right
+	if opt.Density > 0 {
+		switch opt.Style {
+		case Maze:
+			f.maze(r, opt.Density)
+		case Rooms:
+			f.rooms(r, opt.Density)
+		case Corridors:
+			f.corridors(r, opt.Density)
+		default:
+			f.pillars(r, opt.Density)
+		}
+	}
+
+	// Make the field symmetric by copying its upper half onto the lower half
+	for row := top; row <= (top+bottom)/2; row++ {
+		for col := left; col <= right; col++ {
+			p := f.rotate(Point{row, col})
+			f.wall[p.Row][p.Col] = f.wall[row][col]
+		}
+	}
+
+	// Border
+	for col := 1; col <= f.cols; col++ {
+		f.wall[FirstMapRow][col] = true
+		f.wall[f.rows][col] = true
+	}
+	for row := FirstMapRow; row <= f.rows; row++ {
+		f.wall[row][1] = true
+		f.wall[row][f.cols] = true
+	}
+
+	// Start in the upper half, with a clear way ahead
+	start := Start{Point{top + 3 + r.Intn((bottom-top)/2-7), left + 3 + r.Intn(right-left-6)}, Direction(1 + r.Intn(4))}
+	if start.Direction == Up || start.Direction == Down {
+		start.Direction = Left + Direction(r.Intn(2))
+	}
+	p := start.Point
+	for i := 0; i < 8; i++ {
+		f.wall[p.Row][p.Col] = false
+		q := f.rotate(p)
+		f.wall[q.Row][q.Col] = false
+		p = start.Direction.Step(p)
+		if p.Col <= left || p.Col >= right {
+			break
+		}
+	}
+
+	lvl := &Level{
+		Name:    fmt.Sprintf("%s %d", opt.Style, opt.Seed),
+		Numbers: 9,
+		Rows:    f.rows,
+		Cols:    f.cols,
+		Start: [2]Start{
+			start,
+			{f.rotate(start.Point), start.Direction.Opposite()},
+		},
+	}
+	for row := FirstMapRow; row <= f.rows; row++ {
+		for col := 1; col <= f.cols; col++ {
+			if f.wall[row][col] {
+				lvl.Walls = append(lvl.Walls, Point{row, col})
+			}
+		}
+	}
+	return lvl
+}
+
+// pillars scatters small blocks over the field.
+func (f *field) pillars(r *rand.Rand, density float64) {
+	top, bottom, left, right := f.top, f.bottom, f.left, f.right
+	for f.density() < density {
+		row := top + 2 + r.Intn(bottom-top-4)
+		col := left + 2 + r.Intn(right-left-4)
+		f.rect(row, col, row+1+r.Intn(5), col+r.Intn(3), true)
+	}
+}
+
+// corridors draws long bars with gaps, all horizontal or all vertical.
+func (f *field) corridors(r *rand.Rand, density float64) {
+	top, bottom, left, right := f.top, f.bottom, f.left, f.right
+	vertical := r.Intn(2) == 0
+	for f.density() < density {
+		if vertical {
+			col := left + 3 + r.Intn(right-left-6)
+			f.rect(top+1, col, bottom-1, col, true)
+			gap := top + 3 + r.Intn(bottom-top-10)
+			f.rect(gap, col, gap+3+r.Intn(4), col, false)
+		} else {
+			row := top + 3 + r.Intn(bottom-top-6)
+			f.rect(row, left+1, row, right-1, true)
+			gap := left + 3 + r.Intn(right-left-14)
+			f.rect(row, gap, row, gap+5+r.Intn(6), false)
+		}
+	}
+}
+
+// rooms splits the field into rooms with doors between them.
+func (f *field) rooms(r *rand.Rand, density float64) {
+	// The smaller the rooms, the denser the walls
+	minSize := int(3 / density)
+	if minSize < 8 {
+		minSize = 8
+	}
+	var split func(row1, col1, row2, col2 int)
+	split = func(row1, col1, row2, col2 int) {
+		h, w := row2-row1+1, col2-col1+1
+		if w >= 2*minSize && h >= 4 && (w >= 2*h || h < minSize) {
+			col := col1 + minSize/2 + r.Intn(w-minSize)
+			f.rect(row1, col, row2, col, true)
+			door := row1 + r.Intn(h-3)
+			f.rect(door, col, door+3, col, false)
+			split(row1, col1, row2, col-1)
+			split(row1, col+1, row2, col2)
+		} else if h >= minSize && w >= 6 {
+			row := row1 + minSize/4 + r.Intn(h-minSize/2)
+			f.rect(row, col1, row, col2, true)
+			door := col1 + r.Intn(w-5)
+			f.rect(row, door, row, door+5, false)
+			split(row1, col1, row-1, col2)
+			split(row+1, col1, row2, col2)
+		}
+	}
+	split(f.top, f.left, f.bottom, f.right)
+}
+
+// maze draws a maze with wide passages and some loops.
+func (f *field) maze(r *rand.Rand, density float64) {
+	// The narrower the passages, the denser the walls
+	size := int(1.6 / density)
+	if size < 4 {
+		size = 4
+	}
+	// The cells line up with the border
+	rows, cols := (f.rows-FirstMapRow)/size, (f.cols-1)/size
+	if rows < 2 || cols < 2 {
+		return
+	}
+	for row := 0; row <= rows; row++ {
+		f.rect(FirstMapRow+row*size, 1, FirstMapRow+row*size, 1+cols*size, true)
+	}
+	for col := 0; col <= cols; col++ {
+		f.rect(FirstMapRow, 1+col*size, FirstMapRow+rows*size, 1+col*size, true)
+	}
+
+	// Knock down walls between cells, depth first
+	visited := make([][]bool, rows)
+	for i := range visited {
+		visited[i] = make([]bool, cols)
+	}
+	open := func(row, col int, dir Direction) {
+		r1, c1 := FirstMapRow+row*size, 1+col*size
+		switch dir {
+		case Up:
+			f.rect(r1, c1+1, r1, c1+size-1, false)
+		case Down:
+			f.rect(r1+size, c1+1, r1+size, c1+size-1, false)
+		case Left:
+			f.rect(r1+1, c1, r1+size-1, c1, false)
+		case Right:
+			f.rect(r1+1, c1+size, r1+size-1, c1+size, false)
+		}
+	}
+	var visit func(row, col int)
+	visit = func(row, col int) {
+		visited[row][col] = true
+		for _, i := range r.Perm(4) {
+			dir := Direction(1 + i)
+			p := dir.Step(Point{row, col})
+			if p.Row < 0 || p.Row >= rows || p.Col < 0 || p.Col >= cols || visited[p.Row][p.Col] {
+				continue
+			}
+			open(row, col, dir)
+			visit(p.Row, p.Col)
+		}
+	}
+	visit(r.Intn(rows), r.Intn(cols))
+
+	// Loops, so snakes do not get stuck in dead ends
+	for i := 0; i < rows*cols/3; i++ {
+		open(r.Intn(rows), r.Intn(cols), Direction(1+r.Intn(4)))
+	}
+}
@@ -0,0 +1,55 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	sizes := []struct{ rows, cols int }{
+		{0, 0}, // Rows by Cols
+		{MinGenRows, MinGenCols},
+		{31, 57},
+		{50, 100},
+	}
+	for _, style := range Styles {
+		for _, size := range sizes {
+			for seed := int64(1); seed <= 5; seed++ {
+				opt := GenOptions{Seed: seed, Style: style, Density: 0.3, Rows: size.rows, Cols: size.cols}
+				lvl := Generate(opt)
+				name := lvl.Name
+				rows, cols := lvl.Size()
+				if size.rows > 0 && (rows < size.rows || cols != size.cols || rows%2 != 0) {
+					t.Errorf("%s: size %dx%d, want %dx%d with an even number of rows", name, cols, rows, size.cols, size.rows)
+				}
+				if !reflect.DeepEqual(lvl, Generate(opt)) {
+					t.Errorf("%s: the same options create another level", name)
+				}
+				for _, p := range lvl.Validate() {
+					if p.Severity == Error {
+						t.Errorf("%s: %v", name, p)
+					}
+				}
+
+				// The level looks the same rotated by 180 degrees
+				rotate := func(p Point) Point {
+					return Point{FirstMapRow + rows - p.Row, cols + 1 - p.Col}
+				}
+				walls := make(map[Point]bool)
+				for _, p := range lvl.Walls {
+					walls[p] = true
+				}
+				for _, p := range lvl.Walls {
+					if q := rotate(p); !walls[q] {
+						t.Errorf("%s: wall at %d,%d, but not at %d,%d", name, p.Row, p.Col, q.Row, q.Col)
+						break
+					}
+				}
+				start1, start2 := lvl.Start[0], lvl.Start[1]
+				if start2.Point != rotate(start1.Point) || start2.Direction != start1.Direction.Opposite() {
+					t.Errorf("%s: snake 2 starts at %+v, want the start of snake 1 %+v rotated", name, start2, start1)
+				}
+			}
+		}
+	}
+}
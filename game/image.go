@@ -0,0 +1,120 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// ImageOptions tell LevelFromImage which colors mean what.
+type ImageOptions struct {
+	Wall      color.Color
+	Starts    [2]color.Color // Where the players start
+	Tolerance int            // Maximum difference per color channel, 0 to 255
+	Name      string
+
+	// Scale is the number of pixels per point in each direction. If 0, it
+	// is the one making the image the size of the original playing field,
+	// or 1.
+	Scale int
+}
+
+// LevelFromImage converts an image into a level. The image has a pixel
+// (or a square of Scale by Scale pixels) for each point of the playing
+// field, which sets the size of the level; the two top rows of the field
+// are not part of a level and are ignored. Images of 80x48 pixels (or a
+// multiple) only show the rows of a level of the original size. Pixels of
+// the wall color become walls, the pixels of the start colors mark where
+// the players start, and all others, transparent ones included, are
+// free. Each player heads in the direction with the longest free way
+// ahead.
+func LevelFromImage(img image.Image, opt ImageOptions) (*Level, error) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	scale := opt.Scale
+	if scale == 0 {
+		scale = 1
+		if w%Cols == 0 && (h == w/Cols*Rows || h == w/Cols*(Rows-FirstMapRow+1)) {
+			scale = w / Cols
+		}
+	}
+	if scale < 1 {
+		return nil, errors.New("scale must be at least 1")
+	}
+	if w%scale != 0 || h%scale != 0 {
+		return nil, fmt.Errorf("image is %dx%d pixels, not a multiple of %d", w, h, scale)
+	}
+	cols, rows := w/scale, h/scale
+	shown := rows // Rows of the field shown in the image
+	if cols == Cols && rows == Rows-FirstMapRow+1 {
+		rows = Rows
+	}
+	if rows-FirstMapRow+1 < MinMapRows || rows%2 != 0 || cols < MinMapCols {
+		return nil, fmt.Errorf("image is %dx%d points, want an even number of rows and at least %dx%d", cols, rows, MinMapCols, MinMapRows+FirstMapRow-1)
+	}
+	firstRow := rows - shown + 1 // Row of the field in the first pixel row
+
+	match := func(c, want color.Color) bool {
+		c1 := color.NRGBAModel.Convert(c).(color.NRGBA)
+		c2 := color.NRGBAModel.Convert(want).(color.NRGBA)
+		if c1.A == 0 {
+			return false // Transparent pixels are free, whatever their color
+		}
+		d := func(a, b uint8) int {
+			return abs(int(a) - int(b))
+		}
+		return d(c1.R, c2.R) <= opt.Tolerance && d(c1.G, c2.G) <= opt.Tolerance && d(c1.B, c2.B) <= opt.Tolerance
+	}
+
+	lvl := &Level{Name: opt.Name, Numbers: 9, Rows: rows, Cols: cols}
+	wall := newGrid(rows, cols)
+	var starts [2][]Point
+	for row := FirstMapRow; row <= rows; row++ {
+		for col := 1; col <= cols; col++ {
+			// Use the pixel in the middle of the block
+			x := b.Min.X + (col-1)*scale + scale/2
+			y := b.Min.Y + (row-firstRow)*scale + scale/2
+			c := img.At(x, y)
+			p := Point{row, col}
+			switch {
+			case match(c, opt.Wall):
+				wall[row][col] = true
+				lvl.Walls = append(lvl.Walls, p)
+			case match(c, opt.Starts[0]):
+				starts[0] = append(starts[0], p)
+			case match(c, opt.Starts[1]):
+				starts[1] = append(starts[1], p)
+			}
+		}
+	}
+
+	free := func(p Point) bool {
+		return lvl.Contains(p) && !wall[p.Row][p.Col]
+	}
+	for a, points := range starts {
+		if len(points) == 0 {
+			return nil, fmt.Errorf("no pixel of the start color of player %d", a+1)
+		}
+		// A marker may cover several points; start at the one in its middle
+		p := points[len(points)/2]
+		best := 0
+		for _, dir := range []Direction{Up, Down, Left, Right} {
+			n := 0
+			for q := dir.Step(p); free(q); q = dir.Step(q) {
+				n++
+			}
+			if n > best {
+				best = n
+				lvl.Start[a] = Start{p, dir}
+			}
+		}
+		if best == 0 {
+			return nil, fmt.Errorf("player %d starts at %d,%d, which is surrounded by walls", a+1, p.Row, p.Col)
+		}
+	}
+	if lvl.Start[0].Point == lvl.Start[1].Point {
+		return nil, errors.New("players start at the same point")
+	}
+	return lvl, nil
+}
@@ -0,0 +1,42 @@
+package game
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestLevelFromTransparentImage(t *testing.T) {
+	// A wall around a transparent field, which is black underneath
+	img := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	black := color.NRGBA{0, 0, 0, 255}
+	for x := 0; x < 10; x++ {
+		img.Set(x, 2, black)
+		img.Set(x, 9, black)
+	}
+	for y := 2; y < 10; y++ {
+		img.Set(0, y, black)
+		img.Set(9, y, black)
+	}
+	red, blue := color.NRGBA{255, 0, 0, 255}, color.NRGBA{0, 0, 255, 255}
+	img.Set(2, 5, red)
+	img.Set(7, 5, blue)
+
+	lvl, err := LevelFromImage(img, ImageOptions{Wall: color.Black, Starts: [2]color.Color{red, blue}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(lvl.Walls), 2*10+2*6; got != want {
+		t.Errorf("%d walls, want %d around the field", got, want)
+	}
+	for a, want := range []Point{{6, 3}, {6, 8}} {
+		if got := lvl.Start[a].Point; got != want {
+			t.Errorf("player %d starts at %v, want %v", a+1, got, want)
+		}
+	}
+	for _, p := range lvl.Validate() {
+		if p.Severity == Error {
+			t.Error(p)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package game
+
+// Level sets the game level.
+func (g *Game) Level(whatToDo int) {
+	switch whatToDo {
+	case StartOver:
+		g.CurLevel = 1
+		if g.StartLevel > 1 {
+			g.CurLevel = g.StartLevel
+		}
+	case NextLevel:
+		g.CurLevel++
+	}
+
+	// Initialize Snakes
+	sammy := g.Snakes
+	for a := range sammy {
+		sammy[a].Length = 2
+		sammy[a].Alive = true
+		g.bodies[a].Reset()
+	}
+	g.Number = 1
+	g.NumberRow = 0
+
+	lvl := g.CurrentLevel()
+	rows, cols := lvl.Size()
+	g.Arena.Resize(rows, cols)
+	g.wrap = lvl.Wrap
+	if g.Borderless {
+		g.wrap = WrapBoth
+	}
+	for _, p := range lvl.Walls {
+		if g.Borderless && onEdge(p, rows, cols) {
+			continue
+		}
+		g.Arena.Put(p.Row, p.Col, Cell{Kind: CellWall})
+	}
+	g.startObstacles(lvl)
+	g.portals = lvl.Portals
+	g.triggers, g.closing, g.tick = lvl.Triggers, nil, 0
+	for i := range lvl.Triggers {
+		if t := &lvl.Triggers[i]; t.Cause == OnPlate {
+			g.Arena.SetFloor(t.Plate.Row, t.Plate.Col, Cell{Kind: CellPlate})
+		}
+	}
+	for i, portal := range lvl.Portals {
+		for _, p := range portal {
+			g.Arena.Put(p.Row, p.Col, Cell{CellPortal, i})
+		}
+	}
+	for a, start := range lvl.Start {
+		sammy[a].Row = start.Row
+		sammy[a].Col = start.Col
+		sammy[a].Direction = start.Direction
+	}
+
+	if g.Players == 1 {
+		sammy[1].Row = 0
+	}
+}
+
+// CurrentLevel returns the layout of the current level. Levels beyond the
+// last one of g.Levels are generated, or repeat the last one.
+func (g *Game) CurrentLevel() *Level {
+	n := g.CurLevel
+	if n < 1 {
+		n = 1
+	}
+	if n <= len(g.Levels) {
+		return g.Levels[n-1]
+	}
+	if !g.GenerateLevels && len(g.Levels) > 0 {
+		return g.Levels[len(g.Levels)-1]
+	}
+	lvl, ok := g.generated[n]
+	if !ok {
+		if g.generated == nil {
+			g.generated = make(map[int]*Level)
+		}
+		lvl = GeneratedLevel(g.LevelSeed, n-len(g.Levels)-1, g.LevelRows, g.LevelCols)
+		g.generated[n] = lvl
+	}
+	return lvl
+}
+
+// grid holds a flag for each point of a playing field of rows by cols
+// points, indexed by row and column.
+type grid [][]bool
+
+func newGrid(rows, cols int) grid {
+	g := make(grid, rows+1)
+	for row := range g {
+		g[row] = make([]bool, cols+1)
+	}
+	return g
+}
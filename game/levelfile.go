@@ -0,0 +1,426 @@
+package game
+
+import (
+	"bufio"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A Level is the layout of the playing field and its rules.
+//
+// Levels are stored in text files. A level file starts with metadata, one
+// "key: value" per line, followed by a line "map:" and the map:
+//
+//	name: Level 2
+//	numbers: 9
+//	speed: 0
+//	directions: left right
+//	map:
+//	################################################################################
+//	#..............................................................................#
+//	...
+//
+// The map has a line for each row of the playing field from row 3 on, with
+// a character for each of its columns: '#' is a wall, '.' or ' ' is free,
+// '1' and '2' are the points where the snakes of player 1 and 2 start, and
+// a letter is one end of a portal whose other end is the same letter,
+// see Portal.
+// The size of the map sets the size of the field; the levels of the
+// original game have 48 lines of 80 characters for its 80x50 field. As
+// each character cell of the screen shows two rows, a map has an even
+// number of lines.
+//
+// directions gives the direction each snake starts in. numbers is the
+// number of numbers to eat to complete the level (default 9), and speed is
+// added to the delay between moves in milliseconds (default 0). wrap opens
+// edges of the map, see Wrap: "horizontal", "vertical" or "both" (default
+// "none"); gaps in the border on open edges lead to the other side. Each
+// obstacle line adds a moving wall, see Obstacle and ParseObstacle, and each
+// trigger line walls that change during the level, see Trigger and
+// ParseTrigger.
+type Level struct {
+	Name      string
+	Numbers   int
+	Speed     int
+	Rows      int // Size of the playing field, Rows if 0
+	Cols      int // Cols if 0
+	Wrap      Wrap
+	Walls     []Point
+	Portals   []Portal
+	Obstacles []Obstacle
+	Triggers  []Trigger
+	Start     [2]Start
+}
+
+// Size returns the number of rows and columns of the playing field of
+// the level.
+func (lvl *Level) Size() (rows, cols int) {
+	rows, cols = lvl.Rows, lvl.Cols
+	if rows == 0 {
+		rows = Rows
+	}
+	if cols == 0 {
+		cols = Cols
+	}
+	return rows, cols
+}
+
+// Contains reports whether the given point is part of the map of the
+// level.
+func (lvl *Level) Contains(p Point) bool {
+	rows, cols := lvl.Size()
+	return p.Row >= FirstMapRow && p.Row <= rows && p.Col >= 1 && p.Col <= cols
+}
+
+// Start is where and in which direction a snake starts.
+type Start struct {
+	Point
+	Direction Direction
+}
+
+// FirstMapRow is the row of the playing field shown in the first line of
+// a level map.
+const FirstMapRow = 3
+
+// Smallest map of a level.
+const (
+	MinMapRows = 4
+	MinMapCols = 4
+)
+
+// A ParseError describes a problem with a level file, or another file in
+// the same format: a pack manifest or rules, see LoadPack and ParseRules.
+type ParseError struct {
+	File string
+	Line int
+	Col  int // 0 if the problem is with the whole line
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	if e.Col == 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Col, e.Msg)
+}
+
+var directionNames = map[string]Direction{
+	"up":    Up,
+	"down":  Down,
+	"left":  Left,
+	"right": Right,
+}
+
+// String returns the name of the direction.
+func (d Direction) String() string {
+	for name, dir := range directionNames {
+		if dir == d {
+			return name
+		}
+	}
+	return "Direction(" + strconv.Itoa(int(d)) + ")"
+}
+
+// keyValues reads the "key: value" lines that level files, pack manifests
+// and rules files start with, skipping empty lines.
+type keyValues struct {
+	scanner  *bufio.Scanner
+	file     string
+	line     int // Number of the line read last
+	key      string
+	value    string
+	keyCol   int // Column of the key
+	valueCol int // Column of the value
+	err      error
+}
+
+func newKeyValues(file string, r io.Reader) *keyValues {
+	return &keyValues{scanner: bufio.NewScanner(r), file: file}
+}
+
+// next reads the next line with a key and a value into kv. It returns
+// false at the end of the input, or if there is an error, see kv.err.
+func (kv *keyValues) next() bool {
+	for kv.scanner.Scan() {
+		kv.line++
+		text := kv.scanner.Text()
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		i := strings.Index(text, ":")
+		if i < 0 {
+			kv.err = kv.errorf(0, "expected key: value")
+			return false
+		}
+		key, value := text[:i], text[i+1:]
+		kv.keyCol = column(text, len(key)-len(strings.TrimLeftFunc(key, unicode.IsSpace)))
+		kv.valueCol = column(text, len(text)-len(strings.TrimLeftFunc(value, unicode.IsSpace)))
+		kv.key, kv.value = strings.TrimSpace(key), strings.TrimSpace(value)
+		return true
+	}
+	kv.err = kv.scanner.Err()
+	return false
+}
+
+// errorf returns an error at the given column of the line read last, or
+// with the whole line if col is 0.
+func (kv *keyValues) errorf(col int, format string, a ...interface{}) error {
+	return &ParseError{File: kv.file, Line: kv.line, Col: col, Msg: fmt.Sprintf(format, a...)}
+}
+
+// column returns the column of the character at byte offset i of text.
+func column(text string, i int) int {
+	return utf8.RuneCountInString(text[:i]) + 1
+}
+
+// ParseLevel reads a level file. file is used in error messages.
+func ParseLevel(file string, r io.Reader) (*Level, error) {
+	lvl := &Level{Numbers: 9}
+	kv := newKeyValues(file, r)
+	fail := func(col int, format string, a ...interface{}) (*Level, error) {
+		return nil, kv.errorf(col, format, a...)
+	}
+
+	// Metadata
+	var directions []Direction
+	inMap := false
+	for !inMap && kv.next() {
+		key, value, col := kv.key, kv.value, kv.valueCol
+		var err error
+		switch key {
+		case "name":
+			lvl.Name = value
+		case "numbers":
+			lvl.Numbers, err = strconv.Atoi(value)
+			if err == nil && lvl.Numbers < 1 {
+				return fail(col, "numbers must be at least 1")
+			}
+		case "speed":
+			lvl.Speed, err = strconv.Atoi(value)
+		case "directions":
+			for _, name := range strings.Fields(value) {
+				dir, ok := directionNames[name]
+				if !ok {
+					return fail(col, "unknown direction %q", name)
+				}
+				directions = append(directions, dir)
+			}
+			if len(directions) != 2 {
+				return fail(col, "want a direction for each of the 2 players")
+			}
+		case "wrap":
+			if lvl.Wrap.UnmarshalText([]byte(value)) != nil {
+				return fail(col, "unknown wrap %q, want none, horizontal, vertical or both", value)
+			}
+		case "obstacle":
+			o, err := ParseObstacle(value)
+			if err != nil {
+				return fail(col, "%v", err)
+			}
+			lvl.Obstacles = append(lvl.Obstacles, o)
+		case "trigger":
+			t, err := ParseTrigger(value)
+			if err != nil {
+				return fail(col, "%v", err)
+			}
+			lvl.Triggers = append(lvl.Triggers, t)
+		case "map":
+			inMap = true
+		default:
+			return fail(kv.keyCol, "unknown key %q", key)
+		}
+		if err != nil {
+			return fail(col, "%s is not a number", value)
+		}
+	}
+	if kv.err != nil {
+		return nil, kv.err
+	}
+	if !inMap {
+		return fail(0, "missing map")
+	}
+	if directions == nil {
+		return fail(0, "missing directions")
+	}
+
+	// Map
+	var lines []string
+	for kv.scanner.Scan() {
+		lines = append(lines, kv.scanner.Text())
+	}
+	if err := kv.scanner.Err(); err != nil {
+		return nil, err
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	mapLine := kv.line + 1
+	if len(lines) < MinMapRows || len(lines)%2 != 0 {
+		kv.line = mapLine
+		return fail(0, "map has %d lines, want an even number of at least %d", len(lines), MinMapRows)
+	}
+	lvl.Rows = FirstMapRow - 1 + len(lines)
+	lvl.Cols = len([]rune(lines[0]))
+	if lvl.Cols < MinMapCols {
+		kv.line = mapLine
+		return fail(0, "map line has %d characters, want at least %d", lvl.Cols, MinMapCols)
+	}
+	var found [2]bool
+	var ends [len(portalLetters)][]Point // Portal ends found by letter
+	for i, text := range lines {
+		kv.line = mapLine + i
+		row := FirstMapRow + i
+		if n := len([]rune(text)); n != lvl.Cols {
+			return fail(0, "map line has %d characters, want %d like the first", n, lvl.Cols)
+		}
+		for i, ch := range []rune(text) {
+			col := i + 1
+			switch ch {
+			case '#':
+				lvl.Walls = append(lvl.Walls, Point{row, col})
+			case '.', ' ':
+			case '1', '2':
+				a := int(ch - '1')
+				if found[a] {
+					return fail(col, "player %c starts twice", ch)
+				}
+				found[a] = true
+				lvl.Start[a] = Start{Point{row, col}, directions[a]}
+			default:
+				i := strings.IndexRune(portalLetters, ch)
+				if i < 0 {
+					return fail(col, "unexpected %q in map", ch)
+				}
+				if len(ends[i]) == 2 {
+					return fail(col, "portal %c has more than 2 ends", ch)
+				}
+				ends[i] = append(ends[i], Point{row, col})
+			}
+		}
+	}
+	for a := range found {
+		if !found[a] {
+			kv.line = mapLine - 1 // The line "map:"
+			return fail(0, "map has no start for player %d", a+1)
+		}
+	}
+	for i, points := range ends {
+		switch len(points) {
+		case 1:
+			kv.line = mapLine + points[0].Row - FirstMapRow
+			return fail(points[0].Col, "portal %c has only one end", portalLetters[i])
+		case 2:
+			lvl.Portals = append(lvl.Portals, Portal{points[0], points[1]})
+		}
+	}
+	return lvl, nil
+}
+
+// portalLetters mark the ends of the portals in a map.
+const portalLetters = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// MaxPortals is the number of portals a level file can hold.
+const MaxPortals = len(portalLetters)
+
+// LoadLevel reads the level file with the given name from fsys.
+func LoadLevel(fsys fs.FS, name string) (*Level, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseLevel(name, f)
+}
+
+// LoadLevels reads all level files (*.txt) in directory dir of fsys, in
+// the order of their names.
+func LoadLevels(fsys fs.FS, dir string) ([]*Level, error) {
+	names, err := fs.Glob(fsys, path.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, errors.New("no level files found")
+	}
+	sort.Strings(names)
+	var levels []*Level
+	for _, name := range names {
+		lvl, err := LoadLevel(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, lvl)
+	}
+	return levels, nil
+}
+
+//go:embed levels/*.txt
+var defaultLevels embed.FS
+
+var (
+	defaultOnce sync.Once
+	defaultPack []*Level
+)
+
+// DefaultLevels returns the ten levels of the original game.
+func DefaultLevels() []*Level {
+	defaultOnce.Do(func() {
+		var err error
+		defaultPack, err = LoadLevels(defaultLevels, "levels")
+		if err != nil {
+			panic(err)
+		}
+	})
+	return defaultPack
+}
+
+// WriteTo writes the level in the level file format.
+func (lvl *Level) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "name: %s\nnumbers: %d\nspeed: %d\n", lvl.Name, lvl.Numbers, lvl.Speed)
+	if lvl.Wrap != WrapNone {
+		fmt.Fprintf(&b, "wrap: %s\n", lvl.Wrap)
+	}
+	for i := range lvl.Obstacles {
+		fmt.Fprintf(&b, "obstacle: %s\n", &lvl.Obstacles[i])
+	}
+	for i := range lvl.Triggers {
+		fmt.Fprintf(&b, "trigger: %s\n", &lvl.Triggers[i])
+	}
+	fmt.Fprintf(&b, "directions: %s %s\nmap:\n", lvl.Start[0].Direction, lvl.Start[1].Direction)
+	rows, cols := lvl.Size()
+	grid := make([][]byte, rows-FirstMapRow+1)
+	for i := range grid {
+		grid[i] = []byte(strings.Repeat(".", cols))
+	}
+	for _, p := range lvl.Walls {
+		grid[p.Row-FirstMapRow][p.Col-1] = '#'
+	}
+	for i, portal := range lvl.Portals {
+		if i == MaxPortals {
+			break
+		}
+		for _, p := range portal {
+			grid[p.Row-FirstMapRow][p.Col-1] = portalLetters[i]
+		}
+	}
+	for a, start := range lvl.Start {
+		grid[start.Row-FirstMapRow][start.Col-1] = byte('1' + a)
+	}
+	for _, row := range grid {
+		b.Write(row)
+		b.WriteByte('\n')
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
@@ -0,0 +1,36 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLevelErrors(t *testing.T) {
+	const (
+		start = "directions: right left\nmap:\n"
+		field = "######\n#1..2#\n#....#\n######\n"
+	)
+	tests := []struct {
+		level string
+		want  string
+	}{
+		{"numbers:x\n", "t.txt:1:9: x is not a number"},
+		{"numbers:   x\n", "t.txt:1:12: x is not a number"},
+		{"\n  numbers : 0\n", "t.txt:2:13: numbers must be at least 1"},
+		{"name: Ü\ndirections:\tup sideways\n", "t.txt:2:13: unknown direction \"sideways\""},
+		{"  bogus: 1\n", "t.txt:1:3: unknown key \"bogus\""},
+		{"name: Test\nnonsense\n", "t.txt:2: expected key: value"},
+		{"directions: right left\n", "t.txt:1: missing map"},
+		{start + "######\n#1..2#\n", "t.txt:3: map has 2 lines, want an even number of at least 4"},
+		{start + "######\n#1..2#\n#.....#\n######\n", "t.txt:5: map line has 7 characters, want 6 like the first"},
+		{start + "######\n#1.%2#\n#....#\n######\n", "t.txt:4:4: unexpected '%' in map"},
+		{start + "######\n#1..2#\n#..A.#\n######\n", "t.txt:5:4: portal A has only one end"},
+		{"directions: right left\nmap:\n" + strings.Replace(field, "2", ".", 1), "t.txt:2: map has no start for player 2"},
+	}
+	for _, test := range tests {
+		_, err := ParseLevel("t.txt", strings.NewReader(test.level))
+		if err == nil || err.Error() != test.want {
+			t.Errorf("ParseLevel(%q) = %v, want %s", test.level, err, test.want)
+		}
+	}
+}
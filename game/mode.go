@@ -0,0 +1,51 @@
+package game
+
+import "fmt"
+
+// Mode is the kind of game played on the levels.
+type Mode int
+
+// Modes of play.
+const (
+	Nibbles    Mode = iota // Eat the numbers to complete the levels, the original game
+	Tron                   // Light cycles: no numbers, trails stay, the last snake moving wins the round
+	TimeAttack             // Complete each level as fast as possible
+	Survival               // Numbers keep coming on one level, and a snake has only one life
+	Endless                // Numbers keep coming on one level
+	CoOp                   // The snakes play as a team, with one pool of lives and one score
+)
+
+var modeNames = []string{"nibbles", "tron", "time-attack", "survival", "endless", "co-op"}
+
+// Modes returns all modes, in order.
+func Modes() []Mode {
+	return []Mode{Nibbles, Tron, TimeAttack, Survival, Endless, CoOp}
+}
+
+// Loops reports whether the numbers of a level start over after the last
+// one in the mode, so that the level never ends.
+func (m Mode) Loops() bool {
+	return m == Survival || m == Endless
+}
+
+// String returns the name of the mode.
+func (m Mode) String() string {
+	if m < 0 || int(m) >= len(modeNames) {
+		return fmt.Sprintf("Mode(%d)", int(m))
+	}
+	return modeNames[m]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (m Mode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *Mode) UnmarshalText(text []byte) error {
+	if i := index(modeNames, string(text)); i >= 0 {
+		*m = Mode(i)
+		return nil
+	}
+	return fmt.Errorf("unknown mode %q", text)
+}
@@ -0,0 +1,277 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ObstacleKind is the way an obstacle moves.
+type ObstacleKind int
+
+// Kinds of obstacles.
+const (
+	Slide  ObstacleKind = 1 + iota // A bar sliding back and forth
+	Rotate                         // An arm turning around its first point
+	Door                           // A wall opening and closing
+)
+
+var obstacleNames = map[string]ObstacleKind{
+	"slide":  Slide,
+	"rotate": Rotate,
+	"door":   Door,
+}
+
+// String returns the name of the kind, as used in level files.
+func (k ObstacleKind) String() string {
+	for name, kind := range obstacleNames {
+		if kind == k {
+			return name
+		}
+	}
+	return "ObstacleKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// An Obstacle is a piece of wall that moves while the level is played. It
+// starts as the straight line of points from From to To, which runs
+// horizontally, vertically or diagonally.
+//
+// A Slide moves Distance points in direction Dir and back again, one point
+// every Every moves of the snakes. A Rotate turns around From, clockwise
+// by an eighth of a turn every Every moves. A Door is closed for Every
+// moves, then open for Open moves.
+//
+// An obstacle never moves onto a snake, the number, a start or the point a
+// snake is about to move to; it waits until the way is clear.
+type Obstacle struct {
+	Kind     ObstacleKind
+	From, To Point
+	Dir      Direction
+	Distance int
+	Every    int
+	Open     int
+}
+
+// A Frame is a position of an obstacle, kept for Ticks moves.
+type Frame struct {
+	Points []Point
+	Ticks  int
+}
+
+// line returns the points from p to q, which must be on a horizontal,
+// vertical or diagonal line.
+func line(p, q Point) []Point {
+	dr, dc := sign(q.Row-p.Row), sign(q.Col-p.Col)
+	n := abs(q.Row - p.Row)
+	if n == 0 {
+		n = abs(q.Col - p.Col)
+	}
+	points := make([]Point, 0, n+1)
+	for i := 0; i <= n; i++ {
+		points = append(points, Point{p.Row + i*dr, p.Col + i*dc})
+	}
+	return points
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// arms are the directions of a rotating arm, clockwise from up.
+var arms = [8][2]int{{-1, 0}, {-1, 1}, {0, 1}, {1, 1}, {1, 0}, {1, -1}, {0, -1}, {-1, -1}}
+
+// Frames returns the positions the obstacle goes through, over and over.
+func (o *Obstacle) Frames() []Frame {
+	start := line(o.From, o.To)
+	var frames []Frame
+	switch o.Kind {
+	case Slide:
+		shift := func(n int) []Point {
+			points := make([]Point, len(start))
+			for i, p := range start {
+				for j := 0; j < n; j++ {
+					p = o.Dir.Step(p)
+				}
+				points[i] = p
+			}
+			return points
+		}
+		for n := 0; n <= o.Distance; n++ {
+			frames = append(frames, Frame{shift(n), o.Every})
+		}
+		for n := o.Distance - 1; n > 0; n-- {
+			frames = append(frames, Frame{shift(n), o.Every})
+		}
+	case Rotate:
+		dr, dc := sign(o.To.Row-o.From.Row), sign(o.To.Col-o.From.Col)
+		first := 0
+		for i, arm := range arms {
+			if arm == [2]int{dr, dc} {
+				first = i
+			}
+		}
+		length := len(start) - 1
+		for i := range arms {
+			arm := arms[(first+i)%len(arms)]
+			end := Point{o.From.Row + length*arm[0], o.From.Col + length*arm[1]}
+			frames = append(frames, Frame{line(o.From, end), o.Every})
+		}
+	case Door:
+		frames = []Frame{{start, o.Every}, {nil, o.Open}}
+	}
+	return frames
+}
+
+// String returns the obstacle as written in level files, like
+// "slide 10,20 10,24 right 8 every 3", "rotate 25,40 25,46 every 10" or
+// "door 30,10 30,14 closed 20 open 20".
+func (o *Obstacle) String() string {
+	from := fmt.Sprintf("%s %d,%d %d,%d", o.Kind, o.From.Row, o.From.Col, o.To.Row, o.To.Col)
+	switch o.Kind {
+	case Slide:
+		return fmt.Sprintf("%s %s %d every %d", from, o.Dir, o.Distance, o.Every)
+	case Door:
+		return fmt.Sprintf("%s closed %d open %d", from, o.Every, o.Open)
+	}
+	return fmt.Sprintf("%s every %d", from, o.Every)
+}
+
+// ParseObstacle parses an obstacle as returned by Obstacle.String.
+func ParseObstacle(s string) (Obstacle, error) {
+	var o Obstacle
+	fields := strings.Fields(s)
+	if len(fields) < 3 {
+		return o, fmt.Errorf("want kind and two points like 10,20")
+	}
+	kind, ok := obstacleNames[fields[0]]
+	if !ok {
+		return o, fmt.Errorf("unknown obstacle %q, want slide, rotate or door", fields[0])
+	}
+	o.Kind = kind
+	var err error
+	if o.From, o.To, err = parseLine(fields[1], fields[2]); err != nil {
+		return o, err
+	}
+
+	// Numbers following the given words
+	numbers := func(rest []string, words ...string) ([]int, error) {
+		if len(rest) != 2*len(words) {
+			return nil, fmt.Errorf("want %s n at the end", strings.Join(words, " n "))
+		}
+		var ns []int
+		for i, word := range words {
+			n, err := strconv.Atoi(rest[2*i+1])
+			if rest[2*i] != word || err != nil || n < 1 {
+				return nil, fmt.Errorf("want %s n with n at least 1", word)
+			}
+			ns = append(ns, n)
+		}
+		return ns, nil
+	}
+	var ns []int
+	switch kind {
+	case Slide:
+		if len(fields) < 5 {
+			return o, fmt.Errorf("want direction and distance after the points")
+		}
+		if o.Dir, ok = directionNames[fields[3]]; !ok {
+			return o, fmt.Errorf("unknown direction %q", fields[3])
+		}
+		if o.Distance, err = strconv.Atoi(fields[4]); err != nil || o.Distance < 1 {
+			return o, fmt.Errorf("distance %q is not a number of at least 1", fields[4])
+		}
+		ns, err = numbers(fields[5:], "every")
+	case Rotate:
+		if o.From == o.To {
+			return o, fmt.Errorf("arm has no length")
+		}
+		ns, err = numbers(fields[3:], "every")
+	case Door:
+		if ns, err = numbers(fields[3:], "closed", "open"); err == nil {
+			o.Open = ns[1]
+		}
+	}
+	if err != nil {
+		return o, err
+	}
+	o.Every = ns[0]
+	return o, nil
+}
+
+// obstacle is an obstacle in play.
+type obstacle struct {
+	frames []Frame
+	frame  int // Current frame
+	ticks  int // Moves since the current frame was shown
+}
+
+// startObstacles puts the obstacles of the level at their first frame.
+func (g *Game) startObstacles(lvl *Level) {
+	g.obstacles = g.obstacles[:0]
+	for i := range lvl.Obstacles {
+		o := obstacle{frames: lvl.Obstacles[i].Frames()}
+		for _, p := range o.frames[0].Points {
+			g.Arena.Put(p.Row, p.Col, Cell{CellObstacle, i})
+		}
+		g.obstacles = append(g.obstacles, o)
+	}
+}
+
+// moveObstacles moves the obstacles whose frame is over to their next
+// frame, unless the way is blocked.
+func (g *Game) moveObstacles() {
+	for i := range g.obstacles {
+		o := &g.obstacles[i]
+		o.ticks++
+		if o.ticks < o.frames[o.frame].Ticks {
+			continue
+		}
+		next := (o.frame + 1) % len(o.frames)
+		old, points := o.frames[o.frame].Points, o.frames[next].Points
+		if !g.canCover(old, points) {
+			continue // Try again next move
+		}
+		for _, p := range old {
+			if !contains(points, p) {
+				g.Arena.Erase(p.Row, p.Col)
+			}
+		}
+		for _, p := range points {
+			if !contains(old, p) {
+				g.Arena.Put(p.Row, p.Col, Cell{CellObstacle, i})
+			}
+		}
+		o.frame, o.ticks = next, 0
+	}
+}
+
+// canCover reports whether an obstacle covering the points old can move to
+// cover points instead.
+func (g *Game) canCover(old, points []Point) bool {
+	lvl := g.CurrentLevel()
+	for _, p := range points {
+		if contains(old, p) {
+			continue
+		}
+		if !g.Arena.Contains(p.Row, p.Col) || g.Arena.PointIsThere(p.Row, p.Col) {
+			return false
+		}
+		if g.NumberRow == RealRow(p.Row) && g.NumberCol == p.Col {
+			return false
+		}
+		for a := 0; a < g.Players; a++ {
+			s := g.Snakes[a]
+			head := Point{s.Row, s.Col}
+			if p == lvl.Start[a].Point || p == head || p == g.Move(head, s.Direction) {
+				return false
+			}
+		}
+	}
+	return true
+}
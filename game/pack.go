@@ -0,0 +1,96 @@
+package game
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// A Pack is a set of levels played one after another.
+//
+// A pack is a directory (or archive) of level files. It may contain a
+// manifest, a file named "manifest" in the format of the level metadata:
+//
+//	name: Original
+//	author: Microsoft
+//	levels: 01.txt 02.txt 03.txt
+//
+// levels lists the level files in the order they are played. Without it,
+// or without a manifest, all level files of the directory are played in
+// the order of their names. The name defaults to the name of the
+// directory.
+type Pack struct {
+	Name   string
+	Author string
+	Levels []*Level
+}
+
+// ManifestFile is the name of the manifest of a pack.
+const ManifestFile = "manifest"
+
+// LoadPack reads the pack in directory dir of fsys. name is the name of
+// the pack if its manifest doesn't have one.
+func LoadPack(fsys fs.FS, dir, name string) (*Pack, error) {
+	pack := &Pack{Name: name}
+	file := path.Join(dir, ManifestFile)
+	f, err := fsys.Open(file)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		pack.Levels, err = LoadLevels(fsys, dir)
+		return pack, err
+	}
+	defer f.Close()
+
+	var names []string
+	kv := newKeyValues(file, f)
+	for kv.next() {
+		switch kv.key {
+		case "name":
+			pack.Name = kv.value
+		case "author":
+			pack.Author = kv.value
+		case "levels":
+			names = strings.Fields(kv.value)
+		default:
+			return nil, kv.errorf(kv.keyCol, "unknown key %q", kv.key)
+		}
+	}
+	if kv.err != nil {
+		return nil, kv.err
+	}
+
+	if names == nil {
+		pack.Levels, err = LoadLevels(fsys, dir)
+		return pack, err
+	}
+	for _, name := range names {
+		lvl, err := LoadLevel(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		pack.Levels = append(pack.Levels, lvl)
+	}
+	return pack, nil
+}
+
+// DefaultPack returns the pack of the ten levels of the original game.
+func DefaultPack() *Pack {
+	return &Pack{Name: "Original", Author: "Microsoft", Levels: DefaultLevels()}
+}
+
+//go:embed levels/coop
+var coopLevels embed.FS
+
+// CoOpPack returns the pack of levels made for CoOp, in which the players
+// open the way for each other with pressure plates.
+func CoOpPack() *Pack {
+	pack, err := LoadPack(coopLevels, "levels/coop", "Co-op")
+	if err != nil {
+		panic(err)
+	}
+	return pack
+}
@@ -0,0 +1,23 @@
+package game
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadPackErrors(t *testing.T) {
+	tests := []struct {
+		manifest string
+		want     string
+	}{
+		{"name: Test\n  title: Test\n", "pack/manifest:2:3: unknown key \"title\""},
+		{"name: Test\nlevels\n", "pack/manifest:2: expected key: value"},
+	}
+	for _, test := range tests {
+		fsys := fstest.MapFS{"pack/manifest": {Data: []byte(test.manifest)}}
+		_, err := LoadPack(fsys, "pack", "pack")
+		if err == nil || err.Error() != test.want {
+			t.Errorf("LoadPack with manifest %q = %v, want %s", test.manifest, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package game
+
+// A Portal is a linked pair of points of a level. A snake running into one
+// of them comes out of the other, heading on in the same direction; its
+// body threads through both, without covering them.
+type Portal [2]Point
+
+// exit returns the partner of the portal end at p, if there is one.
+func exit(portals []Portal, p Point) (Point, bool) {
+	for _, portal := range portals {
+		switch p {
+		case portal[0]:
+			return portal[1], true
+		case portal[1]:
+			return portal[0], true
+		}
+	}
+	return Point{}, false
+}
+
+// travel returns the point one step from p in direction d on a map of a
+// playing field of rows by cols points with the given open edges and
+// portals. A step into a portal continues from its partner, so the point
+// returned is only a portal if the portals lead into each other in a
+// circle.
+func travel(p Point, d Direction, w Wrap, portals []Portal, rows, cols int) Point {
+	p = w.Move(p, d, rows, cols)
+	for i := 0; i < len(portals); i++ {
+		q, ok := exit(portals, p)
+		if !ok {
+			break
+		}
+		p = w.Move(q, d, rows, cols)
+	}
+	return p
+}
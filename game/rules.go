@@ -0,0 +1,124 @@
+package game
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Rules are the numbers a game is played by. Results of games are only
+// comparable if they were played by the same rules.
+type Rules struct {
+	Name       string `json:"name"`
+	Lives      int    `json:"lives"`       // Lives of each snake at the start
+	Penalty    int    `json:"penalty"`     // Points a snake loses by dying
+	Growth     int    `json:"growth"`      // Points a snake grows per value of a number eaten
+	Numbers    int    `json:"numbers"`     // Numbers to eat per level; 0 for the numbers of the level, see Level
+	SpeedUp    int    `json:"speed_up"`    // Milliseconds the delay between moves shrinks per level, when the game speeds up
+	TurnBack   bool   `json:"turn_back"`   // Snakes may turn back on themselves, into their necks
+	KillPoints int    `json:"kill_points"` // Points a snake gets every time the other snake dies running into it, see HitOther and HeadOn
+	Ties       Ties   `json:"ties"`        // What happens when snakes do things in the same move
+}
+
+// Preset rule sets.
+var (
+	// Classic are the numbers of the original game. Its snakes moved one
+	// after the other, so it had no ties; here, snakes hitting the number
+	// together split it, and a head-on collision kills both.
+	Classic = Rules{Name: "Classic 1990", Lives: 5, Penalty: 10, Growth: 4, SpeedUp: 10,
+		Ties: Ties{Number: SplitNumber, HeadOn: BothDie}}
+
+	// Casual is for a relaxed game: more lives, nothing lost by dying,
+	// shorter snakes.
+	Casual = Rules{Name: "Casual", Lives: 9, Growth: 2, SpeedUp: 5,
+		Ties: Ties{Number: ShareNumber, TailChase: true}}
+
+	// Hardcore is for a hard game: few lives, long snakes, a fast speed-up,
+	// and turning back is deadly.
+	Hardcore = Rules{Name: "Hardcore", Lives: 3, Penalty: 20, Growth: 6, SpeedUp: 20, TurnBack: true, KillPoints: 10,
+		Ties: Ties{HeadOn: LongerSurvives}}
+)
+
+// Presets returns the preset rule sets.
+func Presets() []Rules {
+	return []Rules{Classic, Casual, Hardcore}
+}
+
+// ParseRules reads a rules file: lines of key: value like level files,
+//
+//	name: Long Snakes
+//	lives: 5
+//	penalty: 10
+//	growth: 8
+//	numbers: level
+//	speedup: 10
+//	turnback: no
+//	killpoints: 0
+//	ties: split,both-die
+//
+// Rules left out are those of Classic. file is used in error messages.
+func ParseRules(file string, r io.Reader) (Rules, error) {
+	rules := Classic
+	rules.Name = ""
+	kv := newKeyValues(file, r)
+	fail := func(col int, format string, a ...interface{}) (Rules, error) {
+		return rules, kv.errorf(col, format, a...)
+	}
+	for kv.next() {
+		key, value, col := kv.key, kv.value, kv.valueCol
+
+		// number parses value into n, which must be at least min
+		number := func(n *int, min int) error {
+			i, err := strconv.Atoi(value)
+			if err != nil || i < min {
+				return fmt.Errorf("%s is not a number of at least %d", value, min)
+			}
+			*n = i
+			return nil
+		}
+		var err error
+		switch key {
+		case "name":
+			rules.Name = value
+		case "lives":
+			err = number(&rules.Lives, 1)
+		case "penalty":
+			err = number(&rules.Penalty, 0)
+		case "growth":
+			err = number(&rules.Growth, 0)
+		case "numbers":
+			if value == "level" {
+				rules.Numbers = 0
+			} else {
+				err = number(&rules.Numbers, 1)
+			}
+		case "speedup":
+			err = number(&rules.SpeedUp, 0)
+		case "turnback":
+			switch value {
+			case "yes":
+				rules.TurnBack = true
+			case "no":
+				rules.TurnBack = false
+			default:
+				err = fmt.Errorf("want yes or no")
+			}
+		case "killpoints":
+			err = number(&rules.KillPoints, 0)
+		case "ties":
+			err = rules.Ties.UnmarshalText([]byte(value))
+		default:
+			return fail(kv.keyCol, "unknown key %q", key)
+		}
+		if err != nil {
+			return fail(col, "%v", err)
+		}
+	}
+	if kv.err != nil {
+		return rules, kv.err
+	}
+	if rules.Name == "" {
+		return fail(0, "rules have no name")
+	}
+	return rules, nil
+}
@@ -0,0 +1,25 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRulesErrors(t *testing.T) {
+	tests := []struct {
+		rules string
+		want  string
+	}{
+		{"name: Test\nlives:0\n", "r.txt:2:7: 0 is not a number of at least 1"},
+		{"name: Test\nties:   bogus\n", "r.txt:2:9: unknown tie rule \"bogus\", want split, share, both-die, longer-survives or tail-chase"},
+		{"name: Test\n turnback: maybe\n", "r.txt:2:12: want yes or no"},
+		{"\tcolors: 5\n", "r.txt:1:2: unknown key \"colors\""},
+		{"lives: 3\n", "r.txt:1: rules have no name"},
+	}
+	for _, test := range tests {
+		_, err := ParseRules("r.txt", strings.NewReader(test.rules))
+		if err == nil || err.Error() != test.want {
+			t.Errorf("ParseRules(%q) = %v, want %s", test.rules, err, test.want)
+		}
+	}
+}
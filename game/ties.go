@@ -0,0 +1,106 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ties are the rules for what happens when the snakes do something in the
+// same move, see Game.Step. The original game let snake 1 win every tie;
+// the zero value splits the number, lets both snakes die head-on and
+// forbids chasing tails.
+type Ties struct {
+	Number NumberTie // Both snakes hit the number
+	HeadOn HeadOnTie // Both snakes move onto the same point
+
+	// TailChase lets a snake move onto the point the end of a tail, its
+	// own or the other snake's, leaves in the same move. Without it, the
+	// snake dies there.
+	TailChase bool
+}
+
+// NumberTie is what snakes hitting the number in the same move get. Both
+// grow as usual.
+type NumberTie int
+
+// Ways to share a number.
+const (
+	SplitNumber NumberTie = iota // Each gets half of the points, rounded up
+	ShareNumber                  // Each gets all of the points
+)
+
+// HeadOnTie is who dies when the snakes move onto the same point.
+type HeadOnTie int
+
+// Outcomes of head-on collisions.
+const (
+	BothDie        HeadOnTie = iota
+	LongerSurvives           // The shorter snake dies, both if they are as long
+)
+
+var (
+	numberTieNames = []string{"split", "share"}
+	headOnTieNames = []string{"both-die", "longer-survives"}
+)
+
+// String returns the ties as a list of rules separated by commas, like
+// "split,both-die" or "share,longer-survives,tail-chase".
+func (t Ties) String() string {
+	var words []string
+	if t.Number >= 0 && int(t.Number) < len(numberTieNames) {
+		words = append(words, numberTieNames[t.Number])
+	}
+	if t.HeadOn >= 0 && int(t.HeadOn) < len(headOnTieNames) {
+		words = append(words, headOnTieNames[t.HeadOn])
+	}
+	if t.TailChase {
+		words = append(words, "tail-chase")
+	}
+	return strings.Join(words, ",")
+}
+
+// ParseTies parses ties as returned by Ties.String. Rules left out keep
+// their zero value.
+func ParseTies(s string) (Ties, error) {
+	var t Ties
+	for _, word := range strings.Split(s, ",") {
+		word = strings.TrimSpace(word)
+		switch {
+		case word == "":
+		case word == "tail-chase":
+			t.TailChase = true
+		case index(numberTieNames, word) >= 0:
+			t.Number = NumberTie(index(numberTieNames, word))
+		case index(headOnTieNames, word) >= 0:
+			t.HeadOn = HeadOnTie(index(headOnTieNames, word))
+		default:
+			return t, fmt.Errorf("unknown tie rule %q, want split, share, both-die, longer-survives or tail-chase", word)
+		}
+	}
+	return t, nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (t Ties) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *Ties) UnmarshalText(text []byte) error {
+	ties, err := ParseTies(string(text))
+	if err != nil {
+		return err
+	}
+	*t = ties
+	return nil
+}
+
+// index returns the index of s in list, or -1.
+func index(list []string, s string) int {
+	for i, t := range list {
+		if t == s {
+			return i
+		}
+	}
+	return -1
+}
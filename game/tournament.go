@@ -0,0 +1,191 @@
+package game
+
+import (
+	"io"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// DefaultColors are the colors of snake 1, snake 2, walls and background
+// used for games played without a screen.
+var DefaultColors = []int{14, 13, 12, 1}
+
+// Match plays a two-player game between bots by the given rules without a
+// screen, starting at the given level of levels, or of the original levels
+// if levels is nil. Levels beyond those are generated from levelSeed, or
+// from seed if levelSeed is 0. The game ends when a snake has run out of
+// lives or after maxTicks moves.
+func Match(bots [2]Bot, levels []*Level, levelSeed int64, level int, seed int64, maxTicks int, rules Rules) *Game {
+	g := New(2, DefaultColors, seed)
+	g.SetRules(rules)
+	if levels != nil {
+		g.Levels = levels
+	}
+	if levelSeed != 0 {
+		g.LevelSeed = levelSeed
+	}
+	g.CurLevel = level
+	g.Level(SameLevel)
+	for tick := 0; tick < maxTicks && !g.Over(); tick++ {
+		g.PlaceNumber()
+		for a, bot := range bots {
+			g.Turn(a, bot.Decide(g.State(a)))
+		}
+		ev := g.Step()
+		if ev.LevelComplete || ev.Died {
+			for a, bot := range bots {
+				if e, ok := bot.(Ender); ok {
+					e.End(g.State(a))
+				}
+			}
+		}
+		switch {
+		case ev.LevelComplete:
+			g.Level(NextLevel)
+		case ev.Died:
+			g.Level(SameLevel)
+		}
+	}
+	return g
+}
+
+// Winner returns the index of the winning snake of a two-player game, or -1
+// if the game is a draw. A snake with lives left beats one without,
+// otherwise the higher score wins.
+func (g *Game) Winner() int {
+	sammy := g.Snakes
+	switch {
+	case sammy[0].Lives > 0 && sammy[1].Lives <= 0:
+		return 0
+	case sammy[1].Lives > 0 && sammy[0].Lives <= 0:
+		return 1
+	case sammy[0].Score > sammy[1].Score:
+		return 0
+	case sammy[1].Score > sammy[0].Score:
+		return 1
+	}
+	return -1
+}
+
+// Tournament plays every pair of bots against each other on every level
+// with every seed, once from each side.
+type Tournament struct {
+	Bots      []string // Names of registered bots; bots implementing io.Closer are closed after each game
+	Pack      []*Level // Levels to play on; nil for the original levels, empty for generated levels only
+	LevelSeed int64    // Seed of the levels beyond Pack; 0 to use the seed of each game
+	Levels    []int    // Numbers of the levels of Pack to start games on
+	Seeds     []int64
+	MaxTicks  int   // Maximum number of moves per game
+	Rules     Rules // Rules the games are played by
+}
+
+// Standing is the result of a bot in a tournament.
+type Standing struct {
+	Bot    string
+	Played int
+	Won    int
+	Drawn  int
+	Lost   int
+	Score  int // Sum of the scores of all games
+	Kills  int
+	Deaths [causes]int // Deaths by cause, see Snake.Deaths
+}
+
+// Run plays the tournament and returns the standings, best bot first. The
+// games are played in parallel.
+func (t *Tournament) Run() ([]Standing, error) {
+	type match struct {
+		bots  [2]int
+		level int
+		seed  int64
+	}
+	var matches []match
+	for i := range t.Bots {
+		for j := i + 1; j < len(t.Bots); j++ {
+			for _, level := range t.Levels {
+				for _, seed := range t.Seeds {
+					matches = append(matches, match{[2]int{i, j}, level, seed}, match{[2]int{j, i}, level, seed})
+				}
+			}
+		}
+	}
+
+	standings := make([]Standing, len(t.Bots))
+	for i, name := range t.Bots {
+		if _, err := NewBot(name); err != nil {
+			return nil, err
+		}
+		standings[i].Bot = name
+	}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		err error
+	)
+	work := make(chan match)
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for m := range work {
+				var bots [2]Bot
+				var botErr error
+				for a := range bots {
+					if bots[a], botErr = NewBot(t.Bots[m.bots[a]]); botErr != nil {
+						break
+					}
+				}
+				if botErr != nil {
+					mu.Lock()
+					err = botErr
+					mu.Unlock()
+					continue
+				}
+				g := Match(bots, t.Pack, t.LevelSeed, m.level, m.seed, t.MaxTicks, t.Rules)
+				for _, bot := range bots {
+					if c, ok := bot.(io.Closer); ok {
+						c.Close()
+					}
+				}
+				winner := g.Winner()
+				mu.Lock()
+				for a, i := range m.bots {
+					s := &standings[i]
+					s.Played++
+					s.Score += g.Snakes[a].Score
+					s.Kills += g.Snakes[a].Kills
+					for c, n := range g.Snakes[a].Deaths {
+						s.Deaths[c] += n
+					}
+					switch winner {
+					case a:
+						s.Won++
+					case -1:
+						s.Drawn++
+					default:
+						s.Lost++
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, m := range matches {
+		work <- m
+	}
+	close(work)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		if standings[i].Won != standings[j].Won {
+			return standings[i].Won > standings[j].Won
+		}
+		return standings[i].Score > standings[j].Score
+	})
+	return standings, nil
+}
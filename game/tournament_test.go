@@ -0,0 +1,21 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchGeneratedLevels(t *testing.T) {
+	bots := [2]Bot{NewComputer(Novice), NewComputer(Novice)}
+	g := Match(bots, []*Level{}, 42, 1, 7, 0, Classic)
+	got, want := g.CurrentLevel(), GeneratedLevel(42, 0, 0, 0)
+	if !reflect.DeepEqual(got.Walls, want.Walls) {
+		t.Errorf("level 1 is %q, want %q generated from the level seed", got.Name, want.Name)
+	}
+	if reflect.DeepEqual(got.Walls, DefaultLevels()[0].Walls) {
+		t.Errorf("level 1 is the original level 1, want a generated level")
+	}
+	if other := GeneratedLevel(7, 0, 0, 0); reflect.DeepEqual(got.Walls, other.Walls) {
+		t.Errorf("level 1 is generated from the seed of the game, want the level seed")
+	}
+}
@@ -0,0 +1,200 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TriggerCause is what sets off a trigger.
+type TriggerCause int
+
+// Causes of triggers.
+const (
+	OnNumber TriggerCause = 1 + iota // A snake eats the number Trigger.N
+	OnPlate                          // A snake runs onto the pressure plate Trigger.Plate
+	OnTime                           // Trigger.N moves have passed since the level started
+)
+
+var causeNames = map[string]TriggerCause{
+	"number": OnNumber,
+	"plate":  OnPlate,
+	"time":   OnTime,
+}
+
+// String returns the name of the cause, as used in level files.
+func (c TriggerCause) String() string {
+	for name, cause := range causeNames {
+		if cause == c {
+			return name
+		}
+	}
+	return "TriggerCause(" + strconv.Itoa(int(c)) + ")"
+}
+
+// TriggerAction is what a trigger does to its walls.
+type TriggerAction int
+
+// Actions of triggers.
+const (
+	Open   TriggerAction = 1 + iota // Remove the walls
+	Close                           // Put up the walls
+	Toggle                          // Remove the walls that are up and put up the others
+)
+
+var actionNames = map[string]TriggerAction{
+	"open":   Open,
+	"close":  Close,
+	"toggle": Toggle,
+}
+
+// String returns the name of the action, as used in level files.
+func (a TriggerAction) String() string {
+	for name, action := range actionNames {
+		if action == a {
+			return name
+		}
+	}
+	return "TriggerAction(" + strconv.Itoa(int(a)) + ")"
+}
+
+// A Trigger changes the walls on the straight line of points from From to
+// To when something happens during the level. Number and time triggers go
+// off once per level; a pressure plate goes off every time a snake runs
+// onto it. Like obstacles, walls being put up wait for snakes, the number
+// and starts to get out of the way.
+type Trigger struct {
+	Cause    TriggerCause
+	N        int   // Value of the number, or moves, for OnNumber and OnTime
+	Plate    Point // For OnPlate
+	Action   TriggerAction
+	From, To Point
+}
+
+// String returns the trigger as written in level files, like
+// "number 5 open 20,60 26,60", "plate 30,10 toggle 12,40 12,50" or
+// "time 200 close 40,10 40,30".
+func (t *Trigger) String() string {
+	cause := fmt.Sprintf("%s %d", t.Cause, t.N)
+	if t.Cause == OnPlate {
+		cause = fmt.Sprintf("%s %d,%d", t.Cause, t.Plate.Row, t.Plate.Col)
+	}
+	return fmt.Sprintf("%s %s %d,%d %d,%d", cause, t.Action, t.From.Row, t.From.Col, t.To.Row, t.To.Col)
+}
+
+// Points returns the points of the walls the trigger changes.
+func (t *Trigger) Points() []Point {
+	return line(t.From, t.To)
+}
+
+// ParseTrigger parses a trigger as returned by Trigger.String.
+func ParseTrigger(s string) (Trigger, error) {
+	var t Trigger
+	fields := strings.Fields(s)
+	if len(fields) != 5 {
+		return t, fmt.Errorf("want cause, action and two points like number 5 open 20,60 26,60")
+	}
+	cause, ok := causeNames[fields[0]]
+	if !ok {
+		return t, fmt.Errorf("unknown cause %q, want number, plate or time", fields[0])
+	}
+	t.Cause = cause
+	if cause == OnPlate {
+		p, err := parsePoint(fields[1])
+		if err != nil {
+			return t, err
+		}
+		t.Plate = p
+	} else {
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 1 {
+			return t, fmt.Errorf("%q is not a number of at least 1", fields[1])
+		}
+		t.N = n
+	}
+	if t.Action, ok = actionNames[fields[2]]; !ok {
+		return t, fmt.Errorf("unknown action %q, want open, close or toggle", fields[2])
+	}
+	var err error
+	if t.From, t.To, err = parseLine(fields[3], fields[4]); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+// parsePoint parses a point given as row,col.
+func parsePoint(s string) (Point, error) {
+	row, col, ok := strings.Cut(s, ",")
+	r, err1 := strconv.Atoi(row)
+	c, err2 := strconv.Atoi(col)
+	if !ok || err1 != nil || err2 != nil {
+		return Point{}, fmt.Errorf("%q is not a point like 10,20", s)
+	}
+	return Point{r, c}, nil
+}
+
+// parseLine parses the ends of a horizontal, vertical or diagonal line.
+func parseLine(from, to string) (p, q Point, err error) {
+	if p, err = parsePoint(from); err != nil {
+		return p, q, err
+	}
+	if q, err = parsePoint(to); err != nil {
+		return p, q, err
+	}
+	dr, dc := abs(q.Row-p.Row), abs(q.Col-p.Col)
+	if dr != 0 && dc != 0 && dr != dc {
+		return p, q, fmt.Errorf("%s %s is not a horizontal, vertical or diagonal line", from, to)
+	}
+	return p, q, nil
+}
+
+// fire sets off the triggers with the given cause and number, or plate.
+func (g *Game) fire(cause TriggerCause, n int, plate Point) {
+	for i := range g.triggers {
+		t := &g.triggers[i]
+		if t.Cause != cause || cause == OnPlate && t.Plate != plate || cause != OnPlate && t.N != n {
+			continue
+		}
+		for _, p := range t.Points() {
+			wall := contains(g.closing, p) || g.Arena.Cell(p.Row, p.Col).Kind == CellWall
+			switch {
+			case t.Action == Close || t.Action == Toggle && !wall:
+				if !contains(g.closing, p) {
+					g.closing = append(g.closing, p)
+				}
+			case t.Action == Open || t.Action == Toggle && wall:
+				g.open(p)
+			}
+		}
+	}
+	g.closeWalls()
+}
+
+// open removes the wall at p.
+func (g *Game) open(p Point) {
+	for i, q := range g.closing {
+		if q == p {
+			g.closing = append(g.closing[:i], g.closing[i+1:]...)
+			return
+		}
+	}
+	if g.Arena.Cell(p.Row, p.Col).Kind == CellWall {
+		g.Arena.Erase(p.Row, p.Col)
+	}
+}
+
+// closeWalls puts up the walls waiting to be put up whose points are free.
+func (g *Game) closeWalls() {
+	waiting := g.closing[:0]
+	for _, p := range g.closing {
+		if g.Arena.Cell(p.Row, p.Col).Kind == CellWall {
+			continue
+		}
+		if !g.canCover(nil, []Point{p}) {
+			waiting = append(waiting, p)
+			continue
+		}
+		g.Arena.Put(p.Row, p.Col, Cell{Kind: CellWall})
+	}
+	g.closing = waiting
+}
@@ -0,0 +1,248 @@
+package game
+
+import "fmt"
+
+// Severity of a Problem.
+const (
+	Error   = "error"
+	Warning = "warning"
+)
+
+// A Problem is something wrong with a level.
+type Problem struct {
+	Severity string `json:"severity"`
+	Point    *Point `json:"point,omitempty"` // Where the problem is, if at a single point
+	Msg      string `json:"message"`
+}
+
+func (p Problem) String() string {
+	if p.Point != nil {
+		return fmt.Sprintf("%s: %d,%d: %s", p.Severity, p.Point.Row, p.Point.Col, p.Msg)
+	}
+	return fmt.Sprintf("%s: %s", p.Severity, p.Msg)
+}
+
+// Validate checks the level for problems: gaps in the border on edges that
+// are not open, starts in or facing walls, portals that lead into walls or
+// each other, obstacles moving off the field or into walls, starts or
+// portals, triggers that never go off or change walls off the field,
+// starts too close to each other, no place for numbers to appear, places
+// where numbers can appear that the snakes cannot reach, and too little
+// room for the snakes to grow.
+func (lvl *Level) Validate() []Problem {
+	var problems []Problem
+	add := func(severity string, p *Point, format string, a ...interface{}) {
+		problems = append(problems, Problem{severity, p, fmt.Sprintf(format, a...)})
+	}
+
+	rows, cols := lvl.Size()
+	wall := newGrid(rows, cols)
+	for _, p := range lvl.Walls {
+		if lvl.Contains(p) {
+			wall[p.Row][p.Col] = true
+		}
+	}
+	portal := newGrid(rows, cols)
+	for _, ends := range lvl.Portals {
+		for _, p := range ends {
+			if lvl.Contains(p) {
+				portal[p.Row][p.Col] = true
+			}
+		}
+	}
+	inside := lvl.Contains
+	free := func(p Point) bool {
+		return inside(p) && !wall[p.Row][p.Col] && !portal[p.Row][p.Col]
+	}
+
+	// Border
+	gaps := 0
+	var first *Point
+	for row := FirstMapRow; row <= rows; row++ {
+		for col := 1; col <= cols; col++ {
+			closed := lvl.Wrap&WrapVertical == 0 && (row == FirstMapRow || row == rows) ||
+				lvl.Wrap&WrapHorizontal == 0 && (col == 1 || col == cols)
+			if !closed {
+				continue
+			}
+			if !wall[row][col] && !portal[row][col] {
+				if gaps == 0 {
+					first = &Point{row, col}
+				}
+				gaps++
+			}
+		}
+	}
+	if gaps > 0 {
+		add(Error, first, "border is open at %d points where snakes can leave the field", gaps)
+	}
+
+	// Starts
+	for a, start := range lvl.Start {
+		p := start.Point
+		switch {
+		case !inside(p):
+			add(Error, &p, "player %d starts outside the field", a+1)
+		case wall[p.Row][p.Col]:
+			add(Error, &p, "player %d starts in a wall", a+1)
+		case portal[p.Row][p.Col]:
+			add(Error, &p, "player %d starts in a portal", a+1)
+		case !free(lvl.Move(p, start.Direction)):
+			add(Error, &p, "player %d starts facing a wall", a+1)
+		}
+	}
+	// Portals
+	if len(lvl.Portals) > MaxPortals {
+		add(Error, nil, "%d portals, but level files hold at most %d", len(lvl.Portals), MaxPortals)
+	}
+	for _, ends := range lvl.Portals {
+		for _, p := range ends {
+			if !inside(p) {
+				add(Error, &p, "portal outside the field")
+				continue
+			}
+			// A snake can enter the portal from every free point next to it
+			for _, dir := range []Direction{Up, Down, Left, Right} {
+				from := lvl.Wrap.Move(p, dir.Opposite(), rows, cols)
+				if free(from) && !free(lvl.Move(from, dir)) {
+					add(Warning, &p, "snakes heading %s into the portal come out into a wall", dir)
+					break
+				}
+			}
+		}
+	}
+
+	// Obstacles
+	for i := range lvl.Obstacles {
+		o := &lvl.Obstacles[i]
+	frames:
+		for _, frame := range o.Frames() {
+			for _, p := range frame.Points {
+				p := p
+				var msg string
+				switch {
+				case !inside(p):
+					msg = "moves off the field"
+				case wall[p.Row][p.Col]:
+					msg = "moves into a wall"
+				case portal[p.Row][p.Col]:
+					msg = "moves into a portal"
+				case p == lvl.Start[0].Point || p == lvl.Start[1].Point:
+					msg = "moves onto a start"
+				default:
+					continue
+				}
+				add(Error, &p, "obstacle %q %s", o, msg)
+				break frames
+			}
+		}
+	}
+
+	// Triggers
+	for i := range lvl.Triggers {
+		t := &lvl.Triggers[i]
+		switch {
+		case t.Cause == OnNumber && t.N > lvl.Numbers:
+			add(Warning, nil, "trigger %q never goes off, the level has %d numbers", t, lvl.Numbers)
+		case t.Cause == OnPlate && !free(t.Plate):
+			p := t.Plate
+			add(Error, &p, "pressure plate of trigger %q is not on a free point", t)
+		}
+		for _, p := range t.Points() {
+			p := p
+			switch {
+			case !inside(p):
+				add(Error, &p, "trigger %q changes walls off the field", t)
+			case t.Action != Open && (p == lvl.Start[0].Point || p == lvl.Start[1].Point):
+				add(Error, &p, "trigger %q puts up a wall on a start", t)
+			default:
+				continue
+			}
+			break
+		}
+	}
+
+	s1, s2 := lvl.Start[0], lvl.Start[1]
+	switch {
+	case s1.Point == s2.Point:
+		add(Error, &s1.Point, "players start at the same point")
+	case lvl.Move(s1.Point, s1.Direction) == lvl.Move(s2.Point, s2.Direction):
+		p := lvl.Move(s1.Point, s1.Direction)
+		add(Error, &p, "players collide on their first move")
+	case lvl.Move(s1.Point, s1.Direction) == s2.Point || lvl.Move(s2.Point, s2.Direction) == s1.Point:
+		add(Error, &s1.Point, "players start facing each other")
+	}
+
+	// Reachability, counting walls that triggers can remove as open
+	opened := newGrid(rows, cols)
+	for i := range lvl.Triggers {
+		if lvl.Triggers[i].Action == Close {
+			continue
+		}
+		for _, p := range lvl.Triggers[i].Points() {
+			if inside(p) {
+				opened[p.Row][p.Col] = true
+			}
+		}
+	}
+	reached := newGrid(rows, cols)
+	var queue []Point
+	for _, start := range lvl.Start {
+		if free(start.Point) && !reached[start.Row][start.Col] {
+			reached[start.Row][start.Col] = true
+			queue = append(queue, start.Point)
+		}
+	}
+	for i := 0; i < len(queue); i++ {
+		for _, dir := range []Direction{Up, Down, Left, Right} {
+			n := lvl.Move(queue[i], dir)
+			if (free(n) || inside(n) && opened[n.Row][n.Col]) && !reached[n.Row][n.Col] {
+				reached[n.Row][n.Col] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+	places, unreachable := 0, 0
+	first = nil
+	// Numbers are placed like in Game.PlaceNumber
+	lastRow, firstCol, lastCol := rows-1, 2, cols-1
+	if lvl.Wrap&WrapVertical != 0 {
+		lastRow = rows
+	}
+	if lvl.Wrap&WrapHorizontal != 0 {
+		firstCol, lastCol = 1, cols
+	}
+	for row := FirstMapRow; row <= lastRow; row++ {
+		for col := firstCol; col <= lastCol; col++ {
+			p, q := Point{row, col}, Point{Sister(row), col}
+			if !free(p) || !free(q) {
+				continue
+			}
+			places++
+			if reached[p.Row][p.Col] || reached[q.Row][q.Col] {
+				continue
+			}
+			if unreachable == 0 {
+				first = &p
+			}
+			unreachable++
+		}
+	}
+	if places == 0 {
+		add(Error, nil, "there is no place for numbers to appear")
+	}
+	if unreachable > 0 {
+		add(Error, first, "numbers can appear at %d places the snakes cannot reach", unreachable)
+	}
+
+	// Room to grow: at the end of the level, each snake is 2 points long
+	// plus 4 points per value of every number eaten.
+	length := 2
+	for n := 1; n <= lvl.Numbers; n++ {
+		length += 4 * n
+	}
+	if room := len(queue); room < 2*length {
+		add(Warning, nil, "only %d free points reachable, but the snakes grow to %d points together", room, 2*length)
+	}
+	return problems
+}
@@ -0,0 +1,39 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+const tinyLevel = `name: Tiny
+directions: right left
+map:
+######
+#1..2#
+#....#
+######
+`
+
+func TestNoPlaceForNumbers(t *testing.T) {
+	lvl, err := ParseLevel("tiny.txt", strings.NewReader(tinyLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, p := range lvl.Validate() {
+		if p.Severity == Error && strings.Contains(p.Msg, "no place for numbers") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Validate = %v, want an error for no place for numbers", lvl.Validate())
+	}
+
+	g := New(2, []int{14, 13, 12, 1, 15, 4, 3, 2}, 1)
+	g.Levels = []*Level{lvl}
+	g.GenerateLevels = false
+	g.Level(StartOver)
+	if g.PlaceNumber() {
+		t.Errorf("PlaceNumber placed a number at %d,%d on a field without room", g.NumberRow, g.NumberCol)
+	}
+}
@@ -0,0 +1,78 @@
+package game
+
+import "fmt"
+
+// Wrap tells which edges of the map of a level are open: a snake leaving
+// the map there comes back on the opposite edge.
+type Wrap int
+
+// Open edges.
+const (
+	WrapNone       Wrap = iota
+	WrapHorizontal      // The left and right edges
+	WrapVertical        // The top and bottom edges
+	WrapBoth
+)
+
+var wrapNames = []string{"none", "horizontal", "vertical", "both"}
+
+// String returns the name of the open edges, as used in level files.
+func (w Wrap) String() string {
+	if w < 0 || int(w) >= len(wrapNames) {
+		return fmt.Sprintf("Wrap(%d)", int(w))
+	}
+	return wrapNames[w]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (w Wrap) MarshalText() ([]byte, error) {
+	return []byte(w.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (w *Wrap) UnmarshalText(text []byte) error {
+	for i, name := range wrapNames {
+		if name == string(text) {
+			*w = Wrap(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown wrap %q", text)
+}
+
+// Move returns the point one step from p in direction d on a map of a
+// playing field of rows by cols points, coming back on the opposite edge
+// when leaving the map at an open edge.
+func (w Wrap) Move(p Point, d Direction, rows, cols int) Point {
+	p = d.Step(p)
+	if w&WrapHorizontal != 0 {
+		switch p.Col {
+		case 0:
+			p.Col = cols
+		case cols + 1:
+			p.Col = 1
+		}
+	}
+	if w&WrapVertical != 0 {
+		switch p.Row {
+		case FirstMapRow - 1:
+			p.Row = rows
+		case rows + 1:
+			p.Row = FirstMapRow
+		}
+	}
+	return p
+}
+
+// Move returns the point one step from p in direction d on the level,
+// through its open edges and portals.
+func (lvl *Level) Move(p Point, d Direction) Point {
+	rows, cols := lvl.Size()
+	return travel(p, d, lvl.Wrap, lvl.Portals, rows, cols)
+}
+
+// onEdge reports whether p is on the edge of the map of a playing field of
+// rows by cols points.
+func onEdge(p Point, rows, cols int) bool {
+	return p.Row == FirstMapRow || p.Row == rows || p.Col == 1 || p.Col == cols
+}
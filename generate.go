@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gophun/nibbles/game"
+)
+
+// Generate runs the generate command, which writes a generated level in
+// the level file format. It returns the exit code.
+func Generate(args []string) int {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: nibbles generate [flags]")
+		flags.PrintDefaults()
+	}
+	seed := flags.Int64("seed", 1, "generate the level from `seed`")
+	style := flags.String("style", game.Pillars, "`style` of the level: "+strings.Join(game.Styles, ", "))
+	density := flags.Float64("density", 0.2, "share of the field covered by walls, up to 0.5")
+	size := flags.String("size", "80x50", "`size` of the playing field, columns x rows")
+	out := flags.String("o", "", "write the level to `file` instead of standard output")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	known := false
+	for _, s := range game.Styles {
+		known = known || s == *style
+	}
+	if !known {
+		fmt.Fprintf(os.Stderr, "nibbles: unknown style %q\n", *style)
+		return 2
+	}
+
+	rows, cols, err := parseSize(*size)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nibbles:", err)
+		return 2
+	}
+
+	lvl := game.Generate(game.GenOptions{Seed: *seed, Style: *style, Density: *density, Rows: rows, Cols: cols})
+	if *out == "" {
+		lvl.WriteTo(os.Stdout)
+		return 0
+	}
+	if err := SaveLevel(lvl, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "nibbles:", err)
+		return 1
+	}
+	return 0
+}
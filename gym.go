@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/gophun/nibbles/env"
+)
+
+// Gym runs the gym command, which serves the reinforcement learning
+// environment of package env as line-delimited JSON on standard input and
+// output, or to every client connecting to a TCP address. It returns the
+// exit code.
+func Gym(args []string) int {
+	flags := flag.NewFlagSet("gym", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: nibbles gym [flags]")
+		flags.PrintDefaults()
+	}
+	addr := flags.String("addr", "", "listen on TCP `address` instead of using standard input and output")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *addr == "" {
+		if err := env.Serve(os.Stdin, os.Stdout, levels); err != nil {
+			fmt.Fprintln(os.Stderr, "nibbles:", err)
+			return 1
+		}
+		return 0
+	}
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nibbles:", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "serving environments on %s\n", l.Addr())
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "nibbles:", err)
+			return 1
+		}
+		go func() {
+			defer conn.Close()
+			if err := env.Serve(conn, conn, levels); err != nil {
+				fmt.Fprintln(os.Stderr, "nibbles:", err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gophun/nibbles/game"
+)
+
+// ImportPNG runs the import-png command, which converts a PNG image into
+// a level file. It returns the exit code.
+func ImportPNG(args []string) int {
+	flags := flag.NewFlagSet("import-png", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: nibbles import-png [flags] image.png")
+		flags.PrintDefaults()
+		fmt.Fprintln(flags.Output(), "\nThe image has a pixel, or a square of -scale pixels, per point of the\nplaying field; 80x50 is the size of the original one.")
+	}
+	out := flags.String("o", "", "write the level to `file` instead of standard output")
+	name := flags.String("name", "", "`name` of the level (default the name of the image)")
+	wall := hexColor{color.RGBA{0, 0, 0, 255}}
+	flags.Var(&wall, "wall", "`color` of walls")
+	start1 := hexColor{color.RGBA{255, 0, 0, 255}}
+	flags.Var(&start1, "p1", "`color` marking the start of player 1")
+	start2 := hexColor{color.RGBA{0, 0, 255, 255}}
+	flags.Var(&start2, "p2", "`color` marking the start of player 2")
+	tolerance := flags.Int("tolerance", 32, "maximum difference per color channel (0 to 255)")
+	scale := flags.Int("scale", 0, "pixels per point in each direction (default the one making the image 80x50 or 80x48 points, or 1)")
+
+	// Allow flags after the image name
+	var files []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return 2
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		files = append(files, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if len(files) != 1 {
+		flags.Usage()
+		return 2
+	}
+	file := files[0]
+	if *name == "" {
+		*name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nibbles:", err)
+		return 1
+	}
+	img, err := png.Decode(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "nibbles: %s: %v\n", file, err)
+		return 1
+	}
+	lvl, err := game.LevelFromImage(img, game.ImageOptions{
+		Wall:      wall.RGBA,
+		Starts:    [2]color.Color{start1.RGBA, start2.RGBA},
+		Tolerance: *tolerance,
+		Name:      *name,
+		Scale:     *scale,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "nibbles: %s: %v\n", file, err)
+		return 1
+	}
+	for _, p := range lvl.Validate() {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", file, p)
+	}
+
+	if *out == "" {
+		lvl.WriteTo(os.Stdout)
+		return 0
+	}
+	if err := SaveLevel(lvl, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "nibbles:", err)
+		return 1
+	}
+	return 0
+}
+
+// hexColor is a flag.Value for colors written as #rrggbb.
+type hexColor struct {
+	color.RGBA
+}
+
+func (c *hexColor) String() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func (c *hexColor) Set(s string) error {
+	s = strings.TrimPrefix(s, "#")
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil || len(s) != 6 {
+		return fmt.Errorf("want #rrggbb")
+	}
+	c.RGBA = color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 255}
+	return nil
+}
@@ -37,10 +37,21 @@ func Width(columns, rows int) {
 }
 
 func Cls() {
-	clrRect(1, 1, 80, 25)
+	columns, rows := ScreenSize()
+	if columns < 80 {
+		columns = 80
+	}
+	if rows < 25 {
+		rows = 25
+	}
+	clrRect(1, 1, columns, rows)
 	Locate(1, 1)
 }
 
+func ScreenSize() (columns, rows int) {
+	return screen.Size()
+}
+
 func Color(foreground, background int) {
 	ColorFg(foreground)
 	ColorBg(background)
@@ -9,31 +9,26 @@
 
 package main
 
-import . "github.com/gophun/nibbles/internal/basic"
-
-type snakeBody struct {
-	row, col int
-}
-
-// This type defines the player's snake.
-type snakeType struct {
-	head      int
-	length    int
-	row       int
-	col       int
-	direction int
-	lives     int
-	score     int
-	color     int
-	alive     bool
-}
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gophun/nibbles/game"
+	. "github.com/gophun/nibbles/internal/basic"
+)
 
 // This type is used to represent the playing screen in memory.
 // It is used to simulate graphics in text mode, and has some interesting,
 // and slightly advanced methods to increasing the speed of operation.
 // Instead of the normal 80x25 text graphics using "█", we will be
 // using "▄" and "▀" and "█" to mimic an 80x50 pixel screen.
-// Check out functions Set and PointIsThere to see how this is implemented.
+// Check out function Set to see how this is implemented. The rules of the
+// game are implemented by package game, which keeps its own copy of the
+// playing field; this one is used to draw it.
 type arenaType struct {
 	realRow int // Maps the 80x50 point into the real 80x25.
 	color   int // Stores the current color of the point.
@@ -42,30 +37,120 @@ type arenaType struct {
 	sister int
 }
 
-const MaxSnakeLength = 1000
-
-// Parameters to Level function
-const (
-	StartOver = 1 + iota
-	SameLevel
-	NextLevel
-)
+// Human marks a player slot that is not controlled by the computer.
+const Human = 0
 
 var (
 	arena      [][]arenaType
-	curLevel   int
 	colorTable []int
+	levels     = game.DefaultLevels()
+	randomSeed int64 // Seed of the generated levels if levels is empty
+	repeatLast bool  // Repeat the last level instead of generating more
+	startLevel int   // Level to start the game at
+	genRows    int   // Size of generated levels, the size of the screen if 0
+	genCols    int
+	borderless bool // Play without borders, see game.Game.Borderless
+	rules      = game.Classic
+	mode       game.Mode
+	keepScores bool   // Record the results of games, not when testing levels in the editor
+	testPlay   bool   // Playing a level from the editor, which Esc ends
+	profile    string // Name given by the players in ChooseCampaign
+	packName   string // Name of the level pack chosen in ChooseCampaign
+	packs      []*game.Pack
+	campaign   *Campaign // Saves the progress, nil if not playing a campaign
 )
 
 func main() {
+	levelDir := flag.String("levels", "", "play the level pack in `dir` (a directory or zip archive of level files)")
+	packDir := flag.String("packs", filepath.Join(configDir(), "packs"), "offer the level packs in `dir` to choose from")
+	random := flag.Int64("random", 0, "play generated levels only, starting with the one generated from `seed`")
+	size := flag.String("size", "", "play generated levels of `size` columns x rows, like 80x50 (default the size of the terminal)")
+	flag.BoolVar(&borderless, "wrap", false, "play without borders: snakes leaving the field come back on the other side")
+	loadRules := ruleFlags(flag.CommandLine)
+	flag.Func("mode", "start with `mode` nibbles, tron, time-attack, survival, endless or co-op chosen", func(value string) error {
+		return mode.UnmarshalText([]byte(value))
+	})
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: nibbles [flags] [tournament|battlesnake|gym|edit|validate|generate|import-png] [command flags]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *levelDir != "" {
+		pack, err := OpenPack(*levelDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "nibbles:", err)
+			os.Exit(1)
+		}
+		levels = pack.Levels
+		packs = []*game.Pack{pack}
+	}
+
+	if *size != "" {
+		var err error
+		if genRows, genCols, err = parseSize(*size); err != nil {
+			fmt.Fprintln(os.Stderr, "nibbles:", err)
+			os.Exit(2)
+		}
+	}
+
+	var err error
+	if rules, err = loadRules(); err != nil {
+		fmt.Fprintln(os.Stderr, "nibbles:", err)
+		os.Exit(1)
+	}
+
+	if *random != 0 {
+		levels = nil
+		randomSeed = *random
+	}
+
+	if flag.NArg() > 0 {
+		args := flag.Args()[1:]
+		switch flag.Arg(0) {
+		case "tournament":
+			os.Exit(Tournament(args))
+		case "battlesnake":
+			os.Exit(ServeBattlesnake(args))
+		case "gym":
+			os.Exit(Gym(args))
+		case "edit":
+			os.Exit(Edit(args))
+		case "validate":
+			os.Exit(Validate(args))
+		case "generate":
+			os.Exit(Generate(args))
+		case "import-png":
+			os.Exit(ImportPNG(args))
+		}
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if packs == nil && randomSeed == 0 {
+		var errs []error
+		packs, errs = FindPacks(*packDir)
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, "nibbles:", err)
+		}
+	}
+
 	Randomize(Timer())
 	Intro()
 	defer Reset()
-	numPlayers, speed, diff, monitor := GetInputs()
+	ChooseMode()
+	if mode == game.CoOp && *levelDir == "" {
+		packs = append([]*game.Pack{game.CoOpPack()}, packs...)
+	}
+	numPlayers, speed, diff, monitor, computer := GetInputs()
+	ChooseRules()
+	if randomSeed == 0 {
+		ChooseCampaign(packs)
+	}
 	SetColors(monitor)
 	DrawScreen()
+	keepScores = true
 	for {
-		PlayNibbles(numPlayers, speed, diff)
+		PlayNibbles(numPlayers, speed, diff, computer)
 		if !StillWantsToPlay() {
 			break
 		}
@@ -83,17 +168,24 @@ func SetColors(monitor string) {
 }
 
 var (
-	// {snake1, snake2, Walls, Background, Dialogs-Fore, Back}
-	mono   = []int{15, 7, 7, 0, 15, 0}
-	normal = []int{14, 13, 12, 1, 15, 4}
+	// {snake1, snake2, Walls, Background, Dialogs-Fore, Back, Portals, Plates}
+	mono   = []int{15, 7, 7, 0, 15, 0, 8, 8}
+	normal = []int{14, 13, 12, 1, 15, 4, 11, 10}
 )
 
-// Center centers text on given row.
+// Center centers text on given row of the playing field on the screen, or
+// of the 80 column screen before there is one.
 func Center(row int, text string) {
-	Locate(row, CInt(41-float64(Len(text))/2))
+	Locate(row, CInt(float64(screenWidth())/2+1-float64(Len(text))/2))
 	Print(text)
 }
 
+// middleRow returns the row of the text screen in the middle of the
+// playing field.
+func middleRow() int {
+	return screenHeight() / 2
+}
+
 // DrawScreen draws the playing field.
 func DrawScreen() {
 	// initialize screen
@@ -104,34 +196,70 @@ func DrawScreen() {
 	Center(1, "Nibbles!")
 	Center(11, "Initializing Playing Field...")
 
-	// Initialize arena array
-	arena = make([][]arenaType, 50)
+	ResizeArena(game.Rows, game.Cols)
+	Sleep(1) // Adds authenticity
+}
+
+// ResizeArena initializes the arena array for a playing field of the given
+// size and clears the screen.
+func ResizeArena(rows, cols int) {
+	arena = make([][]arenaType, rows)
 	for row := 1; row <= len(arena); row++ {
-		arena[row-1] = make([]arenaType, 80)
-		for col := 1; col <= 80; col++ {
+		arena[row-1] = make([]arenaType, cols)
+		for col := 1; col <= cols; col++ {
 			arena[row-1][col-1].realRow = (row + 1) / 2
 			arena[row-1][col-1].sister = (row%2)*2 - 1
+			arena[row-1][col-1].color = colorTable[3]
 		}
 	}
-	Sleep(1) // Adds authenticity
+	Cls()
+}
+
+// parseSize parses the size of a playing field given as columns x rows,
+// like 80x50.
+func parseSize(s string) (rows, cols int, err error) {
+	c, r, ok := strings.Cut(s, "x")
+	cols, err1 := strconv.Atoi(c)
+	rows, err2 := strconv.Atoi(r)
+	if !ok || err1 != nil || err2 != nil || cols < 1 || rows < 1 {
+		return 0, 0, fmt.Errorf("invalid size %q, want columns x rows like 80x50", s)
+	}
+	return rows, cols, nil
+}
+
+// levelSize returns the size of generated levels: the one given with
+// -size, or the one filling the screen.
+func levelSize() (rows, cols int) {
+	if genRows != 0 {
+		return genRows, genCols
+	}
+	cols, textRows := ScreenSize()
+	return 2 * textRows, cols
 }
 
 // EraseSnake erases snake to facilitate moving through playing field.
-func EraseSnake(snake []snakeType, snakeBod [][2]snakeBody, snakeNum int) {
+func EraseSnake(g *game.Game, snakeNum int) {
+	body := g.Body(snakeNum)
 	for c := 0; c < 10; c++ {
-		for b := snake[snakeNum].length - c; b >= 0; b -= 10 {
-			tail := (snake[snakeNum].head + MaxSnakeLength - b) % MaxSnakeLength
-			Set(snakeBod[tail][snakeNum].row, snakeBod[tail][snakeNum].col, colorTable[3])
+		for b := len(body) - 1 - c; b >= 0; b -= 10 {
+			Set(body[b].Row, body[b].Col, colorTable[3])
 		}
 		SleepMillis(20)
 	}
 }
 
-// GetInputs gets player inputs.
-func GetInputs() (numPlayers, speed int, diff, monitor string) {
+// GetInputs gets player inputs. For each player, computer holds Human or the
+// skill of the computer playing in that slot.
+func GetInputs() (numPlayers, speed int, diff, monitor string, computer [2]int) {
 	Color(7, 0)
 	Cls()
 
+	switch mode {
+	case game.Tron:
+		numPlayers = 2 // Tron needs an opponent
+	case game.CoOp:
+		numPlayers = 2 // and co-op a partner
+	}
 	for numPlayers != 1 && numPlayers != 2 {
 		Locate(5, 4)
 		Print(Space(34))
@@ -171,7 +299,42 @@ func GetInputs() (numPlayers, speed int, diff, monitor string) {
 		monitor = UCase(Input("Monochrome or color monitor (M or C)"))
 	}
 
-	return numPlayers, speed, diff, monitor
+	prompts := []string{"Sammy: human or computer (H or C)", "Jake:  human or computer (H or C)"}
+	computerPlays := false
+	for a := 0; a < numPlayers; a++ {
+		player := ""
+		for player != "H" && player != "C" {
+			Locate(19+a, 50)
+			Print(Space(30))
+			Locate(19+a, 17)
+			player = UCase(Input(prompts[a]))
+		}
+		if player == "C" {
+			computer[a] = game.Expert
+			computerPlays = true
+		}
+	}
+
+	if computerPlays {
+		Locate(22, 17)
+		Print("Computer skill (1 to 3)")
+		Locate(23, 19)
+		Print("1 = Novice   2 = Average   3 = Expert")
+		skill := 0
+		for skill < game.Novice || skill > game.Expert {
+			Locate(22, 40)
+			Print(Space(40))
+			Locate(22, 40)
+			skill = Val(Input(""))
+		}
+		for a := range computer {
+			if computer[a] != Human {
+				computer[a] = skill
+			}
+		}
+	}
+
+	return numPlayers, speed, diff, monitor, computer
 }
 
 // InitColors initializes playing field colors.
@@ -182,16 +345,6 @@ func InitColors() {
 		}
 	}
 	Cls()
-
-	// Set (turn on) pixels for screen border
-	for col := 1; col <= 80; col++ {
-		Set(3, col, colorTable[2])
-		Set(50, col, colorTable[2])
-	}
-	for row := 4; row <= 49; row++ {
-		Set(row, 1, colorTable[2])
-		Set(row, 80, colorTable[2])
-	}
 }
 
 // Intro displays the game introduction.
@@ -215,7 +368,7 @@ func Intro() {
 	Center(16, "                        (Up)                   (Up)      ")
 	Center(17, "P - Pause                ↑                      W       ")
 	Center(18, "                     (Left) ←   → (Right)   (Left) A   D (Right)  ")
-	Center(19, "                         ↓                      S       ")
+	Center(19, "M - Map                  ↓                      S       ")
 	Center(20, "                       (Down)                 (Down)     ")
 	Center(24, "Press any key to continue")
 
@@ -223,365 +376,293 @@ func Intro() {
 	SparklePause()
 }
 
-// Level sets the game level.
-func Level(whatToDo int, sammy []snakeType) {
-	switch whatToDo {
-	case StartOver:
-		curLevel = 1
-	case NextLevel:
-		curLevel++
-	}
-
-	// Initialize Snakes
-	sammy[0].head = 1
-	sammy[0].length = 2
-	sammy[0].alive = true
-	sammy[1].head = 1
-	sammy[1].length = 2
-	sammy[1].alive = true
-
+// Level sets the game level and draws the playing field.
+func Level(whatToDo int, g *game.Game) {
 	InitColors()
+	views, numberText = nil, ""
+	g.Level(whatToDo)
+	SetRings(g.CurrentLevel().Portals, nil)
+	SetViews(heads(g))
+}
 
-	switch curLevel {
-	case 1:
-		sammy[0].row = 25
-		sammy[1].row = 25
-		sammy[0].col = 50
-		sammy[1].col = 30
-		sammy[0].direction = 4
-		sammy[1].direction = 3
-	case 2:
-		for i := 20; i <= 60; i++ {
-			Set(25, i, colorTable[2])
-		}
-		sammy[0].row = 7
-		sammy[1].row = 43
-		sammy[0].col = 60
-		sammy[1].col = 20
-		sammy[0].direction = 3
-		sammy[1].direction = 4
-	case 3:
-		for i := 10; i <= 40; i++ {
-			Set(i, 20, colorTable[2])
-			Set(i, 60, colorTable[2])
-		}
-		sammy[0].row = 25
-		sammy[1].row = 25
-		sammy[0].col = 50
-		sammy[1].col = 30
-		sammy[0].direction = 1
-		sammy[1].direction = 2
-	case 4:
-		for i := 4; i <= 30; i++ {
-			Set(i, 20, colorTable[2])
-			Set(53-i, 60, colorTable[2])
-		}
-		for i := 2; i <= 40; i++ {
-			Set(38, i, colorTable[2])
-			Set(15, 81-i, colorTable[2])
-		}
-		sammy[0].row = 7
-		sammy[1].row = 43
-		sammy[0].col = 60
-		sammy[1].col = 20
-		sammy[0].direction = 3
-		sammy[1].direction = 4
-	case 5:
-		for i := 13; i <= 39; i++ {
-			Set(i, 21, colorTable[2])
-			Set(i, 59, colorTable[2])
-		}
-		for i := 23; i <= 57; i++ {
-			Set(11, i, colorTable[2])
-			Set(41, i, colorTable[2])
-		}
-		sammy[0].row = 25
-		sammy[1].row = 25
-		sammy[0].col = 50
-		sammy[1].col = 30
-		sammy[0].direction = 1
-		sammy[1].direction = 2
-	case 6:
-		for i := 4; i <= 49; i++ {
-			if i > 30 || i < 23 {
-				Set(i, 10, colorTable[2])
-				Set(i, 20, colorTable[2])
-				Set(i, 30, colorTable[2])
-				Set(i, 40, colorTable[2])
-				Set(i, 50, colorTable[2])
-				Set(i, 60, colorTable[2])
-				Set(i, 70, colorTable[2])
-			}
-		}
-		sammy[0].row = 7
-		sammy[1].row = 43
-		sammy[0].col = 65
-		sammy[1].col = 15
-		sammy[0].direction = 2
-		sammy[1].direction = 1
-	case 7:
-		for i := 4; i <= 49; i += 2 {
-			Set(i, 40, colorTable[2])
-		}
-		sammy[0].row = 7
-		sammy[1].row = 43
-		sammy[0].col = 65
-		sammy[1].col = 15
-		sammy[0].direction = 2
-		sammy[1].direction = 1
-	case 8:
-		for i := 4; i <= 40; i++ {
-			Set(i, 10, colorTable[2])
-			Set(53-i, 20, colorTable[2])
-			Set(i, 30, colorTable[2])
-			Set(53-i, 40, colorTable[2])
-			Set(i, 50, colorTable[2])
-			Set(53-i, 60, colorTable[2])
-			Set(i, 70, colorTable[2])
-		}
-		sammy[0].row = 7
-		sammy[1].row = 43
-		sammy[0].col = 65
-		sammy[1].col = 15
-		sammy[0].direction = 2
-		sammy[1].direction = 1
-	case 9:
-		for i := 6; i <= 47; i++ {
-			Set(i, i, colorTable[2])
-			Set(i, i+28, colorTable[2])
-		}
-		sammy[0].row = 40
-		sammy[1].row = 15
-		sammy[0].col = 75
-		sammy[1].col = 5
-		sammy[0].direction = 1
-		sammy[1].direction = 2
-	default:
-		for i := 4; i <= 49; i += 2 {
-			Set(i, 10, colorTable[2])
-			Set(i+1, 20, colorTable[2])
-			Set(i, 30, colorTable[2])
-			Set(i+1, 40, colorTable[2])
-			Set(i, 50, colorTable[2])
-			Set(i+1, 60, colorTable[2])
-			Set(i, 70, colorTable[2])
-		}
-		sammy[0].row = 7
-		sammy[1].row = 43
-		sammy[0].col = 65
-		sammy[1].col = 15
-		sammy[0].direction = 2
-		sammy[1].direction = 1
+// heads returns the heads of the snakes of the players.
+func heads(g *game.Game) []game.Point {
+	var points []game.Point
+	for a := 0; a < g.Players; a++ {
+		points = append(points, game.Point{Row: g.Snakes[a].Row, Col: g.Snakes[a].Col})
 	}
+	return points
 }
 
 // PlayNibbles is the main routine that controls game play.
-func PlayNibbles(numPlayers, speed int, diff string) {
+func PlayNibbles(numPlayers, speed int, diff string, computer [2]int) {
 
 	// Initialize snakes
-	sammyBody := make([][2]snakeBody, MaxSnakeLength)
-	sammy := make([]snakeType, 2)
-	sammy[0].lives = 5
-	sammy[0].score = 0
-	sammy[0].color = colorTable[0]
-	sammy[1].lives = 5
-	sammy[1].score = 0
-	sammy[1].color = colorTable[1]
+	g := game.New(numPlayers, colorTable, Timer())
+	g.Levels = levels
+	g.GenerateLevels = !repeatLast
+	g.StartLevel = startLevel
+	g.Borderless = borderless
+	g.Mode = mode
+	g.SetRules(rules)
+	g.PortalColor = colorTable[6]
+	g.PlateColor = colorTable[7]
+	g.LevelRows, g.LevelCols = levelSize()
+	g.Arena.OnResize = ResizeArena
+	if len(levels) == 0 {
+		g.LevelSeed = randomSeed
+	}
+	g.Arena.OnSet = Set
+	field = &g.Arena
+	defer func() { field = nil }()
+	sammy := g.Snakes
+	var bots [2]game.Bot
+	for a := range bots {
+		if computer[a] != Human {
+			bots[a] = game.NewComputer(computer[a])
+		}
+	}
 
-	Level(StartOver, sammy)
+	Level(game.StartOver, g)
 
 	curSpeed := speed
+	clock := Clock{Mode: g.Mode}
+	clock.Start(g)
+	var played []Result // Levels completed in time attack
 
 	// Play Nibbles until finished
 
-	SpacePause("     Level" + Str(curLevel) + ",  Push Space")
+	SpacePause("     Level" + Str(g.CurLevel) + ",  Push Space")
 
-	for sammy[0].lives > 0 && sammy[1].lives > 0 {
+	for !g.Over() {
 		// Play next round, until either of snake's lives have run out.
 
-		if numPlayers == 1 {
-			sammy[1].row = 0
-		}
-
-		number := 1   // Current number that snakes are trying to run into
-		noNum := true // noNum = true if a number is not on the screen
-		var numberRow, numberCol int
-		playerDied := false
-
-		PrintScore(numPlayers, sammy[0].score, sammy[1].score, sammy[0].lives, sammy[1].lives)
+		PrintScore(numPlayers, sammy[0].Score, sammy[1].Score, sammy[0].Lives, sammy[1].Lives)
+		clock.Print()
 		Play("T160O1>L20CDEDCDL10ECC")
 
-		for !playerDied {
+		for {
 			// Print number if no number exists
-			if noNum {
-				for {
-					numberRow = int(Rnd(1)*47 + 3)
-					numberCol = int(Rnd(1)*78 + 2)
-					sisterRow := numberRow + arena[numberRow-1][numberCol-1].sister
-					if !PointIsThere(numberRow, numberCol, colorTable[3]) && !PointIsThere(sisterRow, numberCol, colorTable[3]) {
-						break
-					}
-				}
-				numberRow = arena[numberRow-1][numberCol-1].realRow
-				noNum = false
+			if g.PlaceNumber() {
 				Color(colorTable[0], colorTable[3])
-				Locate(numberRow, numberCol)
-				Print(Right(Str(number), 1))
+				PrintNumber(g.NumberRow, g.NumberCol, Right(Str(g.Number), 1))
 			}
 
 			// Delay game
-			SleepMillis(curSpeed)
+			delay := curSpeed + g.CurrentLevel().Speed
+			if delay < 1 {
+				delay = 1
+			}
+			SleepMillis(delay)
+			clock.Tick(delay)
+
+			// In survival, the game speeds up all the time
+			if g.Mode == game.Survival && clock.Moves%50 == 0 && curSpeed > 1 {
+				curSpeed--
+			}
 
 			// Get keyboard input & change direction accordingly
 			switch InKey() {
 			case "w", "W":
-				if sammy[1].direction != 2 {
-					sammy[1].direction = 1
-				}
+				g.Turn(1, game.Up)
 			case "s", "S":
-				if sammy[1].direction != 1 {
-					sammy[1].direction = 2
-				}
+				g.Turn(1, game.Down)
 			case "a", "A":
-				if sammy[1].direction != 4 {
-					sammy[1].direction = 3
-				}
+				g.Turn(1, game.Left)
 			case "d", "D":
-				if sammy[1].direction != 3 {
-					sammy[1].direction = 4
-				}
+				g.Turn(1, game.Right)
 			case "\x00H":
-				if sammy[0].direction != 2 {
-					sammy[0].direction = 1
-				}
+				g.Turn(0, game.Up)
 			case "\x00P":
-				if sammy[0].direction != 1 {
-					sammy[0].direction = 2
-				}
+				g.Turn(0, game.Down)
 			case "\x00K":
-				if sammy[0].direction != 4 {
-					sammy[0].direction = 3
-				}
+				g.Turn(0, game.Left)
 			case "\x00M":
-				if sammy[0].direction != 3 {
-					sammy[0].direction = 4
-				}
+				g.Turn(0, game.Right)
 			case "p", "P":
 				SpacePause(" Game Paused ... Push Space  ")
+			case "m", "M":
+				if scrolling {
+					minimap = !minimap
+					RedrawViews()
+				}
+			case "Esc":
+				if testPlay {
+					return
+				}
 			}
 
+			// Let the computer steer its snakes
 			for a := 0; a < numPlayers; a++ {
-				// Move snake
-				switch sammy[a].direction {
-				case 1:
-					sammy[a].row--
-				case 2:
-					sammy[a].row++
-				case 3:
-					sammy[a].col--
-				case 4:
-					sammy[a].col++
+				if bots[a] != nil {
+					g.Turn(a, bots[a].Decide(g.State(a)))
 				}
+			}
 
-				// If snake hits number, respond accordingly
-				if numberRow == (sammy[a].row+1)/2 && numberCol == sammy[a].col {
-					Play("MBO0L16>CCCE")
-					if sammy[a].length < (MaxSnakeLength - 30) {
-						sammy[a].length = sammy[a].length + number*4
-					}
-					sammy[a].score = sammy[a].score + number
-					PrintScore(numPlayers, sammy[0].score, sammy[1].score, sammy[0].lives, sammy[1].lives)
-					number++
-					if number == 10 {
-						EraseSnake(sammy, sammyBody, 0)
-						EraseSnake(sammy, sammyBody, 1)
-						Locate(numberRow, numberCol)
-						Print(" ")
-						Level(NextLevel, sammy)
-						PrintScore(numPlayers, sammy[0].score, sammy[1].score, sammy[0].lives, sammy[1].lives)
-						SpacePause("     Level" + Str(curLevel) + ",  Push Space")
-						if numPlayers == 1 {
-							sammy[1].row = 0
-						}
-						number = 1
-						if diff == "Y" {
-							speed -= 10
-						}
-						curSpeed = speed
-					}
-					noNum = true
-					if curSpeed < 1 {
-						curSpeed = 1
+			ev := g.Step()
+			FollowViews(heads(g))
+			DrawMinimap()
+
+			// If snake hits number, respond accordingly
+			if ev.Ate {
+				Play("MBO0L16>CCCE")
+				clock.Eaten++
+				PrintScore(numPlayers, sammy[0].Score, sammy[1].Score, sammy[0].Lives, sammy[1].Lives)
+			}
+			// A snake that dies as the level is completed loses its life,
+			// but the game moves on to the next level unless it is over
+			complete := ev.LevelComplete && !g.Over()
+			if complete {
+				EraseSnake(g, 0)
+				EraseSnake(g, 1)
+				var times []string
+				if g.Mode == game.TimeAttack {
+					if r, lines, ok := RecordTime(g, computer, clock.Elapsed); ok {
+						played = append(played, r)
+						times = lines
 					}
 				}
+				Level(game.NextLevel, g)
+				clock.Start(g)
+				if campaign != nil {
+					campaign.Reached(g.CurLevel)
+				}
+				PrintScore(numPlayers, sammy[0].Score, sammy[1].Score, sammy[0].Lives, sammy[1].Lives)
+				SpacePause("     Level"+Str(g.CurLevel)+",  Push Space", times...)
+				if diff == "Y" {
+					speed -= g.Rules.SpeedUp
+				}
+				curSpeed = speed
+				if curSpeed < 1 {
+					curSpeed = 1
+				}
 			}
 
-			for a := 0; a < numPlayers; a++ {
-				// If player runs into any point, or the head of the other snake, it dies.
-				if PointIsThere(sammy[a].row, sammy[a].col, colorTable[3]) || (sammy[0].row == sammy[1].row && sammy[0].col == sammy[1].col) {
-					Play("MBO0L32EFGEFDC")
+			// If a player ran into any point, or the head of the other snake, it died.
+			if ev.Died && !complete {
+				Play("MBO0L32EFGEFDC")
+				if g.NumberRow != 0 {
 					ColorBg(colorTable[3])
-					Locate(numberRow, numberCol)
-					Print(" ")
-
-					playerDied = true
-					sammy[a].alive = false
-					sammy[a].lives = sammy[a].lives - 1
-
-					// Otherwise, move the snake, and erase the tail
-				} else {
-					sammy[a].head = (sammy[a].head + 1) % MaxSnakeLength
-					sammyBody[sammy[a].head][a].row = sammy[a].row
-					sammyBody[sammy[a].head][a].col = sammy[a].col
-					tail := (sammy[a].head + MaxSnakeLength - sammy[a].length) % MaxSnakeLength
-					Set(sammyBody[tail][a].row, sammyBody[tail][a].col, colorTable[3])
-					sammyBody[tail][a].row = 0
-					Set(sammy[a].row, sammy[a].col, sammy[a].color)
+					PrintNumber(g.NumberRow, g.NumberCol, " ")
 				}
+				break
 			}
 		}
 
 		curSpeed = speed // Reset speed to initial value
 
-		for a := 0; a < numPlayers; a++ {
-			EraseSnake(sammy, sammyBody, a)
+		if g.Mode == game.Tron {
+			EraseSnake(g, 0)
+			EraseSnake(g, 1)
+			PrintScore(numPlayers, sammy[0].Score, sammy[1].Score, sammy[0].Lives, sammy[1].Lives)
+			text, more := RoundText(g)
+			SpacePause(text, more...)
+		}
+		for a := 0; a < numPlayers && g.Mode != game.Tron; a++ {
+			EraseSnake(g, a)
 
 			// If dead, then erase snake in really cool way
-			if !sammy[a].alive {
-				// Update score
-				sammy[a].score -= 10
-				PrintScore(numPlayers, sammy[0].score, sammy[1].score, sammy[0].lives, sammy[1].lives)
+			if !sammy[a].Alive {
+				PrintScore(numPlayers, sammy[0].Score, sammy[1].Score, sammy[0].Lives, sammy[1].Lives)
 
 				if a == 0 {
-					SpacePause(" Sammy Dies! Push Space! --->")
+					SpacePause(" Sammy Dies! Push Space! --->", DeathText(g, a))
 				} else {
-					SpacePause(" <---- Jake Dies! Push Space ")
+					SpacePause(" <---- Jake Dies! Push Space ", DeathText(g, a))
 				}
 			}
 		}
 
-		Level(SameLevel, sammy)
-		PrintScore(numPlayers, sammy[0].score, sammy[1].score, sammy[0].lives, sammy[1].lives)
+		Level(game.SameLevel, g)
+		PrintScore(numPlayers, sammy[0].Score, sammy[1].Score, sammy[0].Lives, sammy[1].Lives)
+	}
+	PrintStats(g)
+	switch {
+	case g.Mode == game.TimeAttack:
+		ShowTimes(played)
+	case keepScores:
+		RecordScores(g, computer)
 	}
 }
 
-// PointIsThere checks the global arena array to see if the boolean flag is set.
-func PointIsThere(row, col, color int) bool {
-	if row == 0 {
-		return false
+// names are the names of the snakes.
+var names = []string{"Sammy", "Jake"}
+
+// DeathText tells what snake a died of.
+func DeathText(g *game.Game, a int) string {
+	s := g.Snakes[a]
+	switch s.Cause {
+	case game.HitWall:
+		return "Ran into a wall"
+	case game.HitSelf:
+		return "Ran into itself"
+	case game.HitOther:
+		return "Ran into " + names[s.Killer]
+	case game.HeadOn:
+		return "Head-on with " + names[s.Killer]
+	}
+	return ""
+}
+
+// RoundText tells who won a round of Tron and what the others died of.
+func RoundText(g *game.Game) (text string, more []string) {
+	text = "      Draw!  Push Space"
+	for a := 0; a < g.Players; a++ {
+		if g.Snakes[a].Alive {
+			text = " " + names[a] + " wins!  Push Space"
+		} else {
+			more = append(more, names[a]+": "+DeathText(g, a))
+		}
+	}
+	return text, more
+}
+
+// PrintStats shows what the snakes died of during the game and how often
+// they made the other snake die, and waits for the space bar.
+func PrintStats(g *game.Game) {
+	lines := []string{Space(11)}
+	for a := 0; a < g.Players; a++ {
+		lines[0] += fmt.Sprintf("%9s", names[a])
+	}
+	row := func(label string, n func(s game.Snake) int) {
+		line := Left(label+Space(11), 11)
+		for a := 0; a < g.Players; a++ {
+			line += fmt.Sprintf("%9d", n(g.Snakes[a]))
+		}
+		lines = append(lines, line)
+	}
+	for _, c := range game.Causes() {
+		c := c
+		if g.Players == 1 && (c == game.HitOther || c == game.HeadOn) {
+			continue
+		}
+		row(strings.ToUpper(c.String()[:1])+c.String()[1:], func(s game.Snake) int { return s.Deaths[c] })
+	}
+	if g.Players == 2 {
+		row("Kills", func(s game.Snake) int { return s.Kills })
 	}
-	return arena[row-1][col-1].color != color
+	SpacePause("  Game Statistics, Push Space", lines...)
 }
 
 // PrintScore prints players scores and number of lives remaining.
 func PrintScore(numPlayers, score1, score2, lives1, lives2 int) {
 	Color(15, colorTable[3])
+	format := "%7d00" // Scores are in hundreds
+	if mode == game.Tron {
+		format = "Wins: %3d"
+	}
+	if mode == game.CoOp {
+		// One score and one pool of lives for the team
+		Locate(1, 1)
+		PrintUsing("TEAM %7d00  Lives: %2d", score1+score2, lives1)
+		return
+	}
 	if numPlayers == 2 {
 		Locate(1, 1)
-		PrintUsing("%7d00  Lives: %d  <--JAKE", score2, lives2)
+		PrintUsing(format+"  Lives: %d  <--JAKE", score2, lives2)
 	}
-	Locate(1, 49)
-	PrintUsing("SAMMY-->  Lives: %d     %7d00", lives1, score1)
+	Locate(1, screenWidth()-31)
+	PrintUsing("SAMMY-->  Lives: %d     "+format, lives1, score1)
 }
 
 // Set sets row and column on playing field to given color to facilitate moving
@@ -592,62 +673,30 @@ func Set(row, col, color int) {
 	}
 	// assign color to arena
 	arena[row-1][col-1].color = color
-	// Get real row of pixel
-	realRow := arena[row-1][col-1].realRow
-	// Deduce whether pixel is on top▀, or bottom▄
-	topFlag := (arena[row-1][col-1].sister+1)/2 != 0
-	// Get arena row of sister
-	sisterRow := row + arena[row-1][col-1].sister
-	// Determine sister's color
-	sisterColor := arena[sisterRow-1][col-1].color
-
-	Locate(realRow, col)
-
-	if color == sisterColor {
-		// If both points are same
-		Color(color, color)
-		Print("█")
-	} else {
-		// Since you cannot have bright backgrounds determine
-		// the best combo to use.
-		if topFlag {
-			if color > 7 {
-				Color(color, sisterColor)
-				Print("▀")
-			} else {
-				Color(sisterColor, color)
-				Print("▄")
-			}
-		} else {
-			if color > 7 {
-				Color(color, sisterColor)
-				Print("▄")
-			} else {
-				Color(sisterColor, color)
-				Print("▀")
-			}
-		}
+	for i := range views {
+		views[i].draw(row, col)
 	}
 }
 
 // SpacePause pauses game play and waits for space bar to be pressed before
-// continuing.
-func SpacePause(text string) {
+// continuing. The first line of text is shown as it is, the ones below it
+// centered.
+func SpacePause(text string, more ...string) {
+	mid := middleRow() - len(more)/2
 	Color(colorTable[4], colorTable[5])
-	Center(11, "█▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀█")
-	Center(12, "█ "+Left(text+Space(29), 29)+" █")
-	Center(13, "█▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄█")
+	Center(mid-1, "█▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀█")
+	Center(mid, "█ "+Left(text+Space(29), 29)+" █")
+	for i, line := range more {
+		pad := Space((29 - Len(line)) / 2)
+		Center(mid+1+i, "█ "+Left(pad+line+Space(29), 29)+" █")
+	}
+	Center(mid+1+len(more), "█▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄█")
 	for InKey() != "" {
 	}
 	for InKey() != " " {
 	}
 	Color(15, colorTable[3])
-
-	for i := 21; i <= 26; i++ { // Restore the screen background
-		for j := 24; j <= 56; j++ {
-			Set(i, j, arena[i-1][j-1].color)
-		}
-	}
+	RedrawViews() // Restore the screen background
 }
 
 // SparklePause creates a flashing border for the intro screen.
@@ -689,12 +738,13 @@ func SparklePause() {
 
 // StillWantsToPlay determines if users want to play game again.
 func StillWantsToPlay() bool {
+	mid := middleRow()
 	Color(colorTable[4], colorTable[5])
-	Center(10, "█▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀█")
-	Center(11, "█       G A M E   O V E R       █")
-	Center(12, "█                               █")
-	Center(13, "█      Play Again?   (Y/N)      █")
-	Center(14, "█▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄█")
+	Center(mid-2, "█▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀▀█")
+	Center(mid-1, "█       G A M E   O V E R       █")
+	Center(mid, "█                               █")
+	Center(mid+1, "█      Play Again?   (Y/N)      █")
+	Center(mid+2, "█▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄▄█")
 
 	for InKey() != "" {
 	}
@@ -704,11 +754,11 @@ func StillWantsToPlay() bool {
 	}
 
 	Color(15, colorTable[3])
-	Center(10, "                                 ")
-	Center(11, "                                 ")
-	Center(12, "                                 ")
-	Center(13, "                                 ")
-	Center(14, "                                 ")
+	Center(mid-2, "                                 ")
+	Center(mid-1, "                                 ")
+	Center(mid, "                                 ")
+	Center(mid+1, "                                 ")
+	Center(mid+2, "                                 ")
 
 	if kbd == "Y" {
 		return true
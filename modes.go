@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gophun/nibbles/game"
+	. "github.com/gophun/nibbles/internal/basic"
+)
+
+// modeTexts are the titles and descriptions of the modes, by mode.
+var modeTexts = [][2]string{
+	game.Nibbles:    {"Nibbles", "Eat the numbers to get to the next level"},
+	game.Tron:       {"Tron", "No numbers, trails stay: the last snake moving wins"},
+	game.TimeAttack: {"Time Attack", "Finish each level as fast as you can"},
+	game.Survival:   {"Survival", "One life, the numbers keep coming ever faster"},
+	game.Endless:    {"Endless", "The numbers keep coming, the level never changes"},
+	game.CoOp:       {"Co-op", "Two snakes, one team: the lives and score are shared"},
+}
+
+// ChooseMode shows the modes to choose from and lets the players choose.
+// The mode given on the command line is the default.
+func ChooseMode() {
+	Color(7, 0)
+	Cls()
+
+	Locate(3, 20)
+	Print("Modes")
+	for i, m := range game.Modes() {
+		Locate(5+i, 8)
+		PrintUsing("%d  %-12s %s", i+1, modeTexts[m][0], modeTexts[m][1])
+	}
+
+	n := -1
+	for n < 1 || n > len(game.Modes()) {
+		Locate(21, 4)
+		Print(Space(70))
+		Locate(21, 20)
+		text := Input("Mode (1 to " + Str(len(game.Modes())) + ", Enter for " + Str(int(mode)+1) + ")")
+		n = Val(text)
+		if text == "" {
+			n = int(mode) + 1
+		}
+	}
+	mode = game.Modes()[n-1]
+}
+
+// A Clock keeps the time played in modes that count it, in game time: the
+// sum of the delays between moves, so that pauses do not count.
+type Clock struct {
+	Mode    game.Mode
+	Moves   int // Moves since the clock started
+	Elapsed time.Duration
+	Eaten   int           // Numbers eaten in the game
+	Best    time.Duration // Best time for the level in time attack, 0 if there is none
+}
+
+// Start starts the clock over for the current level of g.
+func (c *Clock) Start(g *game.Game) {
+	c.Moves, c.Elapsed = 0, 0
+	if c.Mode == game.TimeAttack {
+		c.Best = LoadScores().BestTime(levelResult(g))
+	}
+}
+
+// Tick counts a move after a delay of ms milliseconds.
+func (c *Clock) Tick(ms int) {
+	c.Moves++
+	c.Elapsed += time.Duration(ms) * time.Millisecond
+	c.Print()
+}
+
+// Print shows the part of the top line that depends on the mode, between
+// the scores of the players.
+func (c *Clock) Print() {
+	var text string
+	switch c.Mode {
+	case game.TimeAttack:
+		text = "Time " + seconds(c.Elapsed)
+		if c.Best > 0 {
+			text += " Best " + seconds(c.Best)
+		}
+	case game.Survival:
+		text = "Time " + seconds(c.Elapsed)
+	case game.Endless:
+		text = "Numbers " + Str(c.Eaten)
+	default:
+		return
+	}
+	Color(15, colorTable[3])
+	Locate(1, 29)
+	PrintUsing("%-19s", Left(text, 19))
+}
+
+// seconds formats d in seconds and tenths.
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.1f", d.Seconds())
+}
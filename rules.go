@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/gophun/nibbles/game"
+	. "github.com/gophun/nibbles/internal/basic"
+)
+
+// LoadRules returns the preset rules whose name, or its first word, is
+// name in any case, like "classic", or else the rules in the file name.
+func LoadRules(name string) (game.Rules, error) {
+	for _, rules := range game.Presets() {
+		if strings.EqualFold(name, rules.Name) || strings.EqualFold(name, strings.Fields(rules.Name)[0]) {
+			return rules, nil
+		}
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return game.Rules{}, err
+	}
+	defer f.Close()
+	return game.ParseRules(name, f)
+}
+
+// ruleFlags defines the flags selecting the rules in flags. The returned
+// function returns the rules once the flags are parsed.
+func ruleFlags(flags *flag.FlagSet) func() (game.Rules, error) {
+	name := flags.String("rules", "classic", "play by the preset `rules` classic, casual or hardcore, or those in the given file")
+	killPoints := flags.Int("kill-points", 0, "give a snake `n` points (in hundreds) every time the other snake runs into it")
+	var ties game.Ties
+	flags.Func("ties", "resolve ties between the snakes with `rules` like share,longer-survives,tail-chase (default split,both-die)", func(value string) error {
+		return ties.UnmarshalText([]byte(value))
+	})
+	return func() (game.Rules, error) {
+		rules, err := LoadRules(*name)
+		if err != nil {
+			return rules, err
+		}
+		loaded := rules
+		flags.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "kill-points":
+				rules.KillPoints = *killPoints
+			case "ties":
+				rules.Ties = ties
+			}
+		})
+		if rules != loaded {
+			rules.Name += "*"
+		}
+		return rules, nil
+	}
+}
+
+// ChooseRules shows the rule sets to choose from, the presets and the
+// rules given on the command line, and lets the players choose.
+func ChooseRules() {
+	Color(7, 0)
+	Cls()
+
+	sets := game.Presets()
+	chosen := 0
+	for i, r := range sets {
+		if r == rules {
+			chosen = i + 1
+		}
+	}
+	if chosen == 0 {
+		sets = append(sets, rules)
+		chosen = len(sets)
+	}
+
+	yesNo := func(b bool, yes, no string) string {
+		if b {
+			return yes
+		}
+		return no
+	}
+	hundreds := func(n int) string {
+		if n == 0 {
+			return "0"
+		}
+		return Str(n) + "00"
+	}
+	rows := []struct {
+		label string
+		value func(r game.Rules) string
+	}{
+		{"Lives", func(r game.Rules) string { return Str(r.Lives) }},
+		{"Points lost dying", func(r game.Rules) string { return hundreds(r.Penalty) }},
+		{"Growth per number", func(r game.Rules) string { return Str(r.Growth) + " x value" }},
+		{"Numbers per level", func(r game.Rules) string { return yesNo(r.Numbers == 0, "level's", Str(r.Numbers)) }},
+		{"Speed-up per level", func(r game.Rules) string { return Str(r.SpeedUp) + " ms" }},
+		{"Turning back", func(r game.Rules) string { return yesNo(r.TurnBack, "deadly", "ignored") }},
+		{"Points for kills", func(r game.Rules) string { return hundreds(r.KillPoints) }},
+		{"Number hit together", func(r game.Rules) string { return yesNo(r.Ties.Number == game.ShareNumber, "shared", "split") }},
+		{"Head-on collision", func(r game.Rules) string {
+			return yesNo(r.Ties.HeadOn == game.LongerSurvives, "longer lives", "both die")
+		}},
+		{"Tail chasing", func(r game.Rules) string { return yesNo(r.Ties.TailChase, "allowed", "deadly") }},
+	}
+
+	Locate(3, 20)
+	Print("Rules")
+	for i, r := range sets {
+		Locate(5, 23+14*i)
+		Print(Str(i + 1))
+		Locate(6, 23+14*i)
+		Print(Left(r.Name, 13))
+	}
+	for j, row := range rows {
+		Locate(8+j, 2)
+		PrintUsing("%-20s", row.label)
+		for i, r := range sets {
+			Locate(8+j, 23+14*i)
+			PrintUsing("%-13s", row.value(r))
+		}
+	}
+
+	n := -1
+	for n < 1 || n > len(sets) {
+		Locate(21, 4)
+		Print(Space(70))
+		Locate(21, 20)
+		text := Input("Rules (1 to " + Str(len(sets)) + ", Enter for " + Str(chosen) + ")")
+		n = Val(text)
+		if text == "" {
+			n = chosen
+		}
+	}
+	rules = sets[n-1]
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/gophun/nibbles/game"
+)
+
+// A Result is the score of a player at the end of a game, as kept in the
+// score file, or in time attack the time a level took. Results are
+// comparable if they were played in the same mode by the same rules and
+// number of players, and times if they were on the same level of the
+// same pack.
+type Result struct {
+	Player    string        `json:"player"`            // Name of the snake
+	Profile   string        `json:"profile,omitempty"` // Name given by the players, if any
+	Score     int           `json:"score"`
+	Level     int           `json:"level"` // Level reached
+	Players   int           `json:"players"`
+	Mode      game.Mode     `json:"mode"`
+	Rules     game.Rules    `json:"rules"`
+	Pack      string        `json:"pack,omitempty"`       // Name of the level pack, in time attack
+	LevelName string        `json:"level_name,omitempty"` // Name of the level completed, in time attack
+	Duration  time.Duration `json:"duration,omitempty"`   // Time the level took, in time attack
+	Time      time.Time     `json:"time"`
+}
+
+// Name returns the name to show for the result: the profile, or the
+// name of the snake.
+func (r Result) Name() string {
+	if r.Profile != "" {
+		return r.Profile
+	}
+	return r.Player
+}
+
+// Scores are the results of all games played.
+type Scores []Result
+
+func scoresFile() string {
+	return filepath.Join(configDir(), "scores.json")
+}
+
+// LoadScores reads the score file. It is empty if nothing was saved.
+func LoadScores() Scores {
+	var scores Scores
+	if data, err := os.ReadFile(scoresFile()); err == nil {
+		json.Unmarshal(data, &scores)
+	}
+	return scores
+}
+
+// Save saves the scores.
+func (s Scores) Save() error {
+	file := scoresFile()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0o644)
+}
+
+// Best returns the n best results comparable to r, best first.
+func (s Scores) Best(r Result, n int) []Result {
+	var best []Result
+	for _, result := range s {
+		if result.Rules == r.Rules && result.Players == r.Players && result.Mode == r.Mode {
+			best = append(best, result)
+		}
+	}
+	sort.SliceStable(best, func(i, j int) bool {
+		return best[i].Score > best[j].Score
+	})
+	if len(best) > n {
+		best = best[:n]
+	}
+	return best
+}
+
+// BestTime returns the best time of the level of r, or 0 if the level was
+// never completed in time attack.
+func (s Scores) BestTime(r Result) time.Duration {
+	var best time.Duration
+	for _, result := range s {
+		if result.Mode == game.TimeAttack && result.Rules == r.Rules && result.Players == r.Players &&
+			result.Pack == r.Pack && result.LevelName == r.LevelName && result.Duration > 0 &&
+			(best == 0 || result.Duration < best) {
+			best = result.Duration
+		}
+	}
+	return best
+}
+
+// levelResult returns a time attack result for the current level of g,
+// without a player or time.
+func levelResult(g *game.Game) Result {
+	return Result{
+		Level:     g.CurLevel,
+		Players:   g.Players,
+		Mode:      g.Mode,
+		Rules:     g.Rules,
+		Pack:      packName,
+		LevelName: g.CurrentLevel().Name,
+	}
+}
+
+// RecordTime adds the time the current level of g took to the score file,
+// once for each human player, and returns the result and the lines that
+// tell how it compares to the best time. ok is false if there are no
+// human players or scores are not kept.
+func RecordTime(g *game.Game, computer [2]int, d time.Duration) (result Result, lines []string, ok bool) {
+	if !keepScores {
+		return result, nil, false
+	}
+	scores := LoadScores()
+	best := scores.BestTime(levelResult(g))
+	for a := 0; a < g.Players; a++ {
+		if computer[a] != Human {
+			continue
+		}
+		result = levelResult(g)
+		result.Player, result.Profile = names[a], profile
+		result.Score = g.Snakes[a].Score
+		result.Duration = d
+		result.Time = time.Now()
+		scores = append(scores, result)
+		ok = true
+	}
+	if !ok {
+		return result, nil, false
+	}
+	scores.Save()
+
+	lines = []string{"Time " + seconds(d) + " seconds"}
+	switch {
+	case best == 0 || d < best:
+		lines = append(lines, "New best time!")
+	default:
+		lines = append(lines, "Best "+seconds(best)+" seconds")
+	}
+	return result, lines, true
+}
+
+// ShowTimes shows the times of the levels completed in a game of time
+// attack with the best times of the levels.
+func ShowTimes(played []Result) {
+	if len(played) == 0 {
+		return
+	}
+	if len(played) > 12 {
+		played = played[len(played)-12:]
+	}
+	scores := LoadScores()
+	lines := []string{played[0].Rules.Name, "", fmt.Sprintf("%-13s %6s %6s", "Level", "Time", "Best")}
+	for _, r := range played {
+		lines = append(lines, fmt.Sprintf("%2d %-10.10s %6s %6s", r.Level, r.LevelName, seconds(r.Duration), seconds(scores.BestTime(r))))
+	}
+	SpacePause("     Best Times, Push Space", lines...)
+}
+
+// RecordScores adds the results of the human players of a finished game
+// to the score file and shows the best results comparable to them. In
+// co-op, the team has one result with the combined score.
+func RecordScores(g *game.Game, computer [2]int) {
+	scores := LoadScores()
+	var last Result
+	for a := 0; a < g.Players; a++ {
+		if computer[a] != Human || g.Mode == game.CoOp && last.Player != "" {
+			continue
+		}
+		last = Result{
+			Player:  names[a],
+			Profile: profile,
+			Score:   g.Snakes[a].Score,
+			Level:   g.CurLevel,
+			Players: g.Players,
+			Mode:    g.Mode,
+			Rules:   g.Rules,
+			Time:    time.Now(),
+		}
+		if g.Mode == game.CoOp {
+			last.Player = names[0] + "+" + names[1]
+			last.Score = g.TeamScore()
+		}
+		scores = append(scores, last)
+	}
+	if last.Player == "" {
+		return
+	}
+	scores.Save()
+
+	lines := []string{modeTexts[g.Mode][0] + ", " + g.Rules.Name, ""}
+	for i, r := range scores.Best(last, 8) {
+		score := fmt.Sprintf("%7d00", r.Score)
+		if r.Mode == game.Tron {
+			score = fmt.Sprintf("%4d wins", r.Score)
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s  %-10.10s L%-2d", i+1, score, r.Name(), r.Level))
+	}
+	SpacePause("    High Scores, Push Space", lines...)
+}
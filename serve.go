@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gophun/nibbles/battlesnake"
+	"github.com/gophun/nibbles/game"
+)
+
+// ServeBattlesnake runs the battlesnake command, which serves a registered
+// bot over the Battlesnake API. It returns the exit code.
+func ServeBattlesnake(args []string) int {
+	flags := flag.NewFlagSet("battlesnake", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: nibbles battlesnake [flags]")
+		flags.PrintDefaults()
+		fmt.Fprintln(flags.Output(), "\nregistered bots:", strings.Join(game.Bots(), ", "))
+	}
+	addr := flags.String("addr", "localhost:8000", "listen on `address`")
+	name := flags.String("bot", "expert", "`name` of the bot to serve")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if _, err := game.NewBot(*name); err != nil {
+		fmt.Fprintln(os.Stderr, "nibbles:", err)
+		return 2
+	}
+
+	srv := battlesnake.NewServer(func() game.Bot {
+		bot, _ := game.NewBot(*name)
+		return bot
+	})
+	fmt.Fprintf(os.Stderr, "serving %s on http://%s\n", *name, *addr)
+	if err := http.ListenAndServe(*addr, srv); err != nil {
+		fmt.Fprintln(os.Stderr, "nibbles:", err)
+		return 1
+	}
+	return 0
+}
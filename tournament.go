@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gophun/nibbles/battlesnake"
+	"github.com/gophun/nibbles/game"
+)
+
+// Tournament runs the tournament command, which plays registered bots
+// against each other without a screen and prints a table of the results.
+// It returns the exit code.
+func Tournament(args []string) int {
+	flags := flag.NewFlagSet("tournament", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: nibbles tournament [flags]")
+		flags.PrintDefaults()
+		fmt.Fprintln(flags.Output(), "\nregistered bots:", strings.Join(game.Bots(), ", "))
+	}
+	wallsAsSnake := flags.Bool("walls-as-snake", false, "send walls to Battlesnake servers as a snake instead of hazards")
+	flags.Func("exec", "register a bot played by an external program, given as `name=command [args]`; may be repeated", func(value string) error {
+		name, command, ok := strings.Cut(value, "=")
+		args := strings.Fields(command)
+		if !ok || name == "" || len(args) == 0 {
+			return errors.New("want name=command")
+		}
+		if _, err := game.NewBot(name); err == nil {
+			return fmt.Errorf("bot %q already exists", name)
+		}
+		if _, err := exec.LookPath(args[0]); err != nil {
+			return err
+		}
+		game.Register(name, func() game.Bot {
+			return game.NewExternal(os.Stderr, args[0], args[1:]...)
+		})
+		return nil
+	})
+	flags.Func("battlesnake", "register a bot played by a Battlesnake server, given as `name=url`; may be repeated", func(value string) error {
+		name, url, ok := strings.Cut(value, "=")
+		if !ok || name == "" || url == "" {
+			return errors.New("want name=url")
+		}
+		if _, err := game.NewBot(name); err == nil {
+			return fmt.Errorf("bot %q already exists", name)
+		}
+		game.Register(name, func() game.Bot {
+			c := battlesnake.NewClient(url, os.Stderr)
+			c.WallsAsSnake = *wallsAsSnake
+			return c
+		})
+		return nil
+	})
+	bots := flags.String("bots", "", "comma-separated `names` of the bots taking part (default all registered bots)")
+	defaultLevels := len(levels)
+	if defaultLevels == 0 {
+		defaultLevels = 10 // Generated levels only
+	}
+	numLevels := flags.Int("levels", defaultLevels, "play levels 1 to `n`")
+	seeds := flags.Int("seeds", 3, "play every game with `n` different seeds")
+	seed := flags.Int64("seed", 1, "first seed")
+	ticks := flags.Int("ticks", 5000, "end games after `n` moves")
+	loadRules := ruleFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	rules, err := loadRules()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nibbles:", err)
+		return 1
+	}
+
+	t := game.Tournament{
+		Bots:     game.Bots(),
+		Pack:     levels,
+		MaxTicks: *ticks,
+
+		Rules: rules,
+	}
+	if randomSeed != 0 {
+		t.Pack, t.LevelSeed = []*game.Level{}, randomSeed
+	}
+	if *bots != "" {
+		t.Bots = strings.Split(*bots, ",")
+	}
+	if len(t.Bots) < 2 {
+		fmt.Fprintln(os.Stderr, "nibbles: a tournament needs at least two bots")
+		return 2
+	}
+	for level := 1; level <= *numLevels; level++ {
+		t.Levels = append(t.Levels, level)
+	}
+	for i := 0; i < *seeds; i++ {
+		t.Seeds = append(t.Seeds, *seed+int64(i))
+	}
+
+	standings, err := t.Run()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "nibbles:", err)
+		return 1
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "\tBot\tPlayed\tWon\tDrawn\tLost\tScore\tAvg\tKills\tWall\tSelf\tOther\tHead-on\t")
+	for i, s := range standings {
+		avg := 0
+		if s.Played > 0 {
+			avg = s.Score / s.Played
+		}
+		fmt.Fprintf(w, "%d.\t%s\t%d\t%d\t%d\t%d\t%d00\t%d00\t%d\t", i+1, s.Bot, s.Played, s.Won, s.Drawn, s.Lost, s.Score, avg, s.Kills)
+		for _, c := range game.Causes() {
+			fmt.Fprintf(w, "%d\t", s.Deaths[c])
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+	return 0
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gophun/nibbles/game"
+)
+
+// Validate runs the validate command, which checks level files for
+// problems. It returns the exit code: 1 if a level has errors (or, with
+// -strict, warnings), otherwise 0.
+func Validate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: nibbles validate [flags] level-files-or-dirs...")
+		flags.PrintDefaults()
+	}
+	asJSON := flags.Bool("json", false, "print the results as JSON")
+	strict := flags.Bool("strict", false, "fail on warnings, too")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	var files []string
+	for _, arg := range flags.Args() {
+		if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			names, _ := filepath.Glob(filepath.Join(arg, "*.txt"))
+			sort.Strings(names)
+			files = append(files, names...)
+			continue
+		}
+		files = append(files, arg)
+	}
+
+	type result struct {
+		File     string         `json:"file"`
+		Name     string         `json:"name,omitempty"`
+		Problems []game.Problem `json:"problems"`
+	}
+	var results []result
+	failed := false
+	for _, file := range files {
+		r := result{File: file, Problems: []game.Problem{}}
+		lvl, err := loadLevelFile(file)
+		if err != nil {
+			r.Problems = append(r.Problems, game.Problem{Severity: game.Error, Msg: err.Error()})
+		} else {
+			r.Name = lvl.Name
+			r.Problems = append(r.Problems, lvl.Validate()...)
+		}
+		for _, p := range r.Problems {
+			if p.Severity == game.Error || *strict {
+				failed = true
+			}
+		}
+		results = append(results, r)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(results)
+	} else {
+		for _, r := range results {
+			if len(r.Problems) == 0 {
+				fmt.Printf("%s: ok\n", r.File)
+			}
+			for _, p := range r.Problems {
+				fmt.Printf("%s: %s\n", r.File, p)
+			}
+		}
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// loadLevelFile reads the level file with the given path. Syntax errors
+// do not mention the file name.
+func loadLevelFile(file string) (*game.Level, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	lvl, err := game.ParseLevel(file, f)
+	var perr *game.ParseError
+	if errors.As(err, &perr) {
+		if perr.Col == 0 {
+			return nil, fmt.Errorf("line %d: %s", perr.Line, perr.Msg)
+		}
+		return nil, fmt.Errorf("line %d, column %d: %s", perr.Line, perr.Col, perr.Msg)
+	}
+	return lvl, err
+}
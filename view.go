@@ -0,0 +1,294 @@
+package main
+
+import (
+	"github.com/gophun/nibbles/game"
+	. "github.com/gophun/nibbles/internal/basic"
+)
+
+// A view shows part of the playing field on the screen. A playing field
+// that fits on the screen is shown as a whole, like in the original game.
+// Bigger ones are shown in views that follow the snakes, side by side in
+// two-player games, below the score line.
+type view struct {
+	top, left  int // Screen position of the upper left corner
+	rows, cols int // Size in rows and columns of the text screen
+	row, col   int // Point of the playing field shown in the upper left corner
+}
+
+var (
+	views     []view
+	scrolling bool // The views follow the snakes
+
+	// The number as printed by PrintNumber, to redraw it
+	numberRow, numberCol int
+	numberText           string
+
+	minimap  bool // Show the overview of the playing field
+	mapScale int  // Columns of the playing field per column of the overview
+
+	rings map[game.Point]bool // Points drawn as rings: the ends of portals
+
+	field *game.Arena // The playing field of the game being played, for the overview
+)
+
+// SetRings sets the points drawn as rings to the ends of the portals and
+// the extra points.
+func SetRings(portals []game.Portal, extra []game.Point) {
+	rings = make(map[game.Point]bool)
+	for _, portal := range portals {
+		rings[portal[0]], rings[portal[1]] = true, true
+	}
+	for _, p := range extra {
+		rings[p] = true
+	}
+}
+
+// screenWidth returns the number of columns the playing field takes up on
+// the screen, or 80 before there is a playing field.
+func screenWidth() int {
+	if arena == nil {
+		return 80
+	}
+	cols, _ := ScreenSize()
+	if len(arena[0]) < cols {
+		return len(arena[0])
+	}
+	return cols
+}
+
+// screenHeight returns the number of rows the playing field takes up on
+// the screen.
+func screenHeight() int {
+	_, rows := ScreenSize()
+	if len(arena)/2 < rows {
+		return len(arena) / 2
+	}
+	return rows
+}
+
+// SetViews lays out the views for the playing field, centered on the
+// given points, one view for each if the field is bigger than the screen,
+// and draws them.
+func SetViews(centers []game.Point) {
+	cols, rows := ScreenSize()
+	scrolling = len(arena[0]) > cols || len(arena)/2 > rows
+	if !scrolling {
+		views = []view{{top: 1, left: 1, rows: len(arena) / 2, cols: len(arena[0]), row: 1, col: 1}}
+		RedrawViews()
+		return
+	}
+
+	// The views share the screen below the score line, with a column
+	// between them.
+	views = make([]view, len(centers))
+	width := (cols - len(centers) + 1) / len(centers)
+	for i := range views {
+		v := &views[i]
+		v.top, v.rows = 2, rows-1
+		v.cols = width
+		v.left = 1 + (len(views)-1-i)*(width+1) // Player 1 on the right, like the score
+		v.center(centers[i])
+	}
+
+	// The overview takes up at most a quarter of the width and a third of
+	// the height of the screen.
+	mapScale = (len(arena[0]) + cols/4 - 1) / (cols / 4)
+	for (len(arena)-game.FirstMapRow+2*mapScale)/(2*mapScale) > rows/3 {
+		mapScale++
+	}
+	RedrawViews()
+}
+
+// center moves the view so that p is in its middle, as far as the
+// playing field allows.
+func (v *view) center(p game.Point) {
+	v.row = clamp(p.Row-v.rows, game.FirstMapRow, len(arena)-2*v.rows+1)
+	if v.row%2 == 0 {
+		// Each row of the screen shows an odd row and the row below it
+		v.row--
+	}
+	v.col = clamp(p.Col-v.cols/2, 1, len(arena[0])-v.cols+1)
+}
+
+func clamp(n, low, high int) int {
+	if n > high {
+		n = high
+	}
+	if n < low {
+		n = low
+	}
+	return n
+}
+
+// FollowViews moves the views that points have come close to the edge of,
+// so they are in the middle again.
+func FollowViews(points []game.Point) {
+	if !scrolling {
+		return
+	}
+	for i := range views {
+		v := &views[i]
+		p := points[i]
+		marginRows, marginCols := v.rows/2, v.cols/4
+		if p.Row >= v.row+marginRows && p.Row < v.row+2*v.rows-marginRows &&
+			p.Col >= v.col+marginCols && p.Col < v.col+v.cols-marginCols {
+			continue
+		}
+		old := *v
+		v.center(p)
+		if *v != old {
+			v.redraw()
+			DrawMinimap()
+		}
+	}
+}
+
+// RedrawViews draws all views, and the lines between them.
+func RedrawViews() {
+	for i := range views {
+		views[i].redraw()
+	}
+	Color(colorTable[4], colorTable[3])
+	for i := 1; i < len(views); i++ {
+		v := views[i]
+		for row := v.top; row < v.top+v.rows; row++ {
+			Locate(row, v.left+v.cols)
+			Print("│")
+		}
+	}
+	DrawMinimap()
+}
+
+func (v *view) redraw() {
+	Color(colorTable[3], colorTable[3])
+	for row := 0; row < v.rows; row++ {
+		Locate(v.top+row, v.left)
+		Print(Space(v.cols))
+	}
+	for row := v.row; row < v.row+2*v.rows && row <= len(arena); row += 2 {
+		for col := v.col; col < v.col+v.cols && col <= len(arena[0]); col++ {
+			v.draw(row, col)
+		}
+	}
+	if numberText != "" && v.shows(numberRow*2-1, numberCol) {
+		Color(colorTable[0], colorTable[3])
+		Locate(v.top+numberRow-(v.row+1)/2, v.left+numberCol-v.col)
+		Print(numberText)
+	}
+}
+
+// shows reports whether the given point is in the view.
+func (v *view) shows(row, col int) bool {
+	return row >= v.row && row < v.row+2*v.rows && col >= v.col && col < v.col+v.cols
+}
+
+// draw draws the character cell of the given point.
+func (v *view) draw(row, col int) {
+	if !v.shows(row, col) {
+		return
+	}
+	color := arena[row-1][col-1].color
+	// Get real row of pixel
+	realRow := arena[row-1][col-1].realRow
+	// Deduce whether pixel is on top▀, or bottom▄
+	topFlag := (arena[row-1][col-1].sister+1)/2 != 0
+	// Get arena row of sister
+	sisterRow := row + arena[row-1][col-1].sister
+	// Determine sister's color
+	sisterColor := arena[sisterRow-1][col-1].color
+
+	Locate(v.top+realRow-(v.row+1)/2, v.left+col-v.col)
+
+	ring, sisterRing := rings[game.Point{Row: row, Col: col}], rings[game.Point{Row: sisterRow, Col: col}]
+	if ring && sisterColor < 8 || sisterRing && color < 8 {
+		// Portals are rings, on what the other point shows
+		fore, back := color, sisterColor
+		if !ring {
+			fore, back = sisterColor, color
+		}
+		if sisterRing && ring {
+			back = colorTable[3]
+		}
+		Color(fore, back)
+		Print("○")
+		return
+	}
+
+	if color == sisterColor {
+		// If both points are same
+		Color(color, color)
+		Print("█")
+	} else {
+		// Since you cannot have bright backgrounds determine
+		// the best combo to use.
+		if topFlag {
+			if color > 7 {
+				Color(color, sisterColor)
+				Print("▀")
+			} else {
+				Color(sisterColor, color)
+				Print("▄")
+			}
+		} else {
+			if color > 7 {
+				Color(color, sisterColor)
+				Print("▄")
+			} else {
+				Color(sisterColor, color)
+				Print("▀")
+			}
+		}
+	}
+}
+
+// PrintNumber prints text at the given row and column of the playing field
+// in the text screen, in the current color. It is used for the number,
+// text " " erases it.
+func PrintNumber(row, col int, text string) {
+	numberRow, numberCol, numberText = row, col, text
+	if text == " " {
+		numberText = ""
+	}
+	for i := range views {
+		v := &views[i]
+		if v.shows(row*2-1, col) {
+			Locate(v.top+row-(v.row+1)/2, v.left+col-v.col)
+			Print(text)
+		}
+	}
+}
+
+// DrawMinimap draws the overview of the playing field in the lower right
+// corner of the screen, if it is turned on and the views do not show the
+// whole field.
+func DrawMinimap() {
+	if !minimap || !scrolling || field == nil {
+		return
+	}
+	screenCols, screenRows := ScreenSize()
+	rows := (len(arena) - game.FirstMapRow + 2*mapScale) / (2 * mapScale)
+	cols := (len(arena[0]) + mapScale - 1) / mapScale
+	top, left := screenRows-rows+1, screenCols-cols+1
+	for r := 0; r < rows; r++ {
+		Locate(top+r, left)
+		for c := 0; c < cols; c++ {
+			// The most important thing in the block of points: a snake, the
+			// number, a wall, or nothing
+			color, rank := 0, 0
+			for row := game.FirstMapRow + r*2*mapScale; row < game.FirstMapRow+(r+1)*2*mapScale && row <= len(arena); row++ {
+				for col := 1 + c*mapScale; col < 1+(c+1)*mapScale && col <= len(arena[0]); col++ {
+					switch cell := field.Cell(row, col); {
+					case cell.Kind == game.CellBody || cell.Kind == game.CellHead:
+						color, rank = colorTable[cell.Owner], 3
+					case cell.Kind == game.CellNumber && rank < 2:
+						color, rank = 15, 2
+					case cell.Occupied() && rank < 1:
+						color, rank = 8, 1
+					}
+				}
+			}
+			Color(color, color)
+			Print("█")
+		}
+	}
+}